@@ -0,0 +1,167 @@
+// Package agent implements a small in-memory secrets cache that is served over a user-only unix socket
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+const dialTimeout = time.Second
+
+type request struct {
+	Op     string        `json:"op"`
+	Name   string        `json:"name,omitempty"`
+	Secret []byte        `json:"secret,omitempty"`
+	TTL    time.Duration `json:"ttl,omitempty"`
+}
+
+type response struct {
+	Secret []byte `json:"secret,omitempty"`
+	Found  bool   `json:"found,omitempty"`
+	Count  int    `json:"count,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SocketPath returns the path of the agent's socket, which lives next to the database unless overridden by KV_AGENT_SOCK
+func SocketPath() string {
+	if socketPath := os.Getenv("KV_AGENT_SOCK"); socketPath != "" {
+		return socketPath
+	}
+
+	return path.Join(path.Dir(common.GetDBPath()), "agent.sock")
+}
+
+// IsRunning reports whether an agent is listening on SocketPath
+func IsRunning() bool {
+	conn, err := net.DialTimeout("unix", SocketPath(), dialTimeout)
+	if err != nil {
+		return false
+	}
+
+	_ = conn.Close()
+	return true
+}
+
+// EnsureRunning starts a background agent if none is running.
+// The started agent exits on its own once it holds no secrets.
+func EnsureRunning() error {
+	if IsRunning() {
+		return nil
+	}
+
+	return Start(DefaultTimeout, true)
+}
+
+// Start runs the agent in the background with the given default timeout, see Serve
+func Start(timeout time.Duration, exitWhenEmpty bool) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"agent", "serve", "--timeout", timeout.String()}
+	if exitWhenEmpty {
+		args = append(args, "--exit-when-empty")
+	}
+
+	// Standard streams are left empty so the agent does not keep the caller's pipes open
+	agentCmd := exec.Command(executable, args...)
+	if err := agentCmd.Start(); err != nil {
+		return err
+	}
+
+	// Do not wait for the agent, it outlives this process
+	_ = agentCmd.Process.Release()
+
+	for range 100 {
+		if IsRunning() {
+			return nil
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return errors.New("agent did not start in time")
+}
+
+// Put stores secret under name for the given duration, or for the agent's default timeout if ttl is zero
+func Put(name string, secret []byte, ttl time.Duration) error {
+	_, err := send(request{Op: "put", Name: name, Secret: secret, TTL: ttl})
+	return err
+}
+
+// Get returns the secret stored under name, if the agent is running and holds it
+func Get(name string) ([]byte, bool) {
+	res, err := send(request{Op: "get", Name: name})
+	if err != nil || !res.Found {
+		return nil, false
+	}
+
+	return res.Secret, true
+}
+
+// Delete drops the secret stored under name, it's a no-op if the agent is not running
+func Delete(name string) error {
+	if !IsRunning() {
+		return nil
+	}
+
+	_, err := send(request{Op: "delete", Name: name})
+	return err
+}
+
+// Clear wipes all secrets held by the agent, it's a no-op if the agent is not running
+func Clear() error {
+	if !IsRunning() {
+		return nil
+	}
+
+	_, err := send(request{Op: "clear"})
+	return err
+}
+
+// Count returns the number of secrets held by the agent
+func Count() (int, error) {
+	res, err := send(request{Op: "count"})
+	return res.Count, err
+}
+
+// Stop wipes all secrets and stops the agent
+func Stop() error {
+	_, err := send(request{Op: "stop"})
+	return err
+}
+
+func send(req request) (response, error) {
+	var res response
+
+	conn, err := net.DialTimeout("unix", SocketPath(), dialTimeout)
+	if err != nil {
+		return res, err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return res, err
+	}
+
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return res, err
+	}
+
+	if res.Error != "" {
+		return res, errors.New(res.Error)
+	}
+
+	return res, nil
+}
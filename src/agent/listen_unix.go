@@ -0,0 +1,16 @@
+//go:build !windows
+
+package agent
+
+import (
+	"net"
+	"syscall"
+)
+
+// listen creates the agent socket at socketPath, accessible only to the current user from the moment it exists
+func listen(socketPath string) (net.Listener, error) {
+	previous := syscall.Umask(0o177)
+	defer syscall.Umask(previous)
+
+	return net.Listen("unix", socketPath)
+}
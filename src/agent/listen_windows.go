@@ -0,0 +1,10 @@
+//go:build windows
+
+package agent
+
+import "net"
+
+// listen creates the agent socket at socketPath, Windows has no umask so it's protected by the access rules of its directory
+func listen(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
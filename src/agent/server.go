@@ -0,0 +1,227 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// How long an agent started with exitWhenEmpty waits for its first secret
+const idleTimeout = 30 * time.Second
+
+// DefaultTimeout is how long secrets are kept when no explicit duration is given
+const DefaultTimeout = 15 * time.Minute
+
+type entry struct {
+	secret []byte
+	timer  *time.Timer
+}
+
+type server struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	timeout       time.Duration
+	exitWhenEmpty bool
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// Serve runs the agent in the foreground until it's stopped.
+// Secrets stored without a duration are kept for timeout.
+// If exitWhenEmpty is set, the agent exits as soon as it no longer holds any secrets.
+func Serve(timeout time.Duration, exitWhenEmpty bool) error {
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %v", timeout)
+	}
+
+	socketPath := SocketPath()
+
+	if IsRunning() {
+		return fmt.Errorf("agent is already running at %q", socketPath)
+	}
+
+	err := os.MkdirAll(path.Dir(socketPath), os.ModeDir|os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// Remove any socket left behind by an agent that did not exit cleanly
+	_ = os.Remove(socketPath)
+
+	// Only the current user may talk to the agent
+	listener, err := listen(socketPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = listener.Close() }()
+
+	s := &server{
+		entries:       map[string]*entry{},
+		timeout:       timeout,
+		exitWhenEmpty: exitWhenEmpty,
+		done:          make(chan struct{}),
+	}
+
+	// Detach from the terminal that started us
+	signal.Ignore(syscall.SIGHUP)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+		case <-s.done:
+		}
+
+		s.clear()
+		_ = listener.Close()
+	}()
+
+	if exitWhenEmpty {
+		time.AfterFunc(idleTimeout, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if len(s.entries) == 0 {
+				s.stop()
+			}
+		})
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	_ = json.NewEncoder(conn).Encode(s.process(req))
+}
+
+func (s *server) process(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case "put":
+		ttl := req.TTL
+		if ttl <= 0 {
+			ttl = s.timeout
+		}
+
+		// Replacing an entry must not count as the agent becoming empty
+		s.drop(req.Name)
+
+		name := req.Name
+		e := &entry{secret: req.Secret}
+		e.timer = time.AfterFunc(ttl, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			// The entry might have been replaced since this timer was set
+			if s.entries[name] == e {
+				s.remove(name)
+			}
+		})
+
+		s.entries[name] = e
+
+		return response{}
+	case "get":
+		entry, found := s.entries[req.Name]
+		if !found {
+			return response{}
+		}
+
+		// Encoded after the lock is released, when the entry may be wiped by its expiry or a delete
+		return response{Found: true, Secret: slices.Clone(entry.secret)}
+	case "delete":
+		s.remove(req.Name)
+		return response{}
+	case "clear":
+		for name := range s.entries {
+			s.remove(name)
+		}
+
+		return response{}
+	case "count":
+		return response{Count: len(s.entries)}
+	case "stop":
+		s.stop()
+		return response{}
+	default:
+		return response{Error: fmt.Sprintf("unsupported operation %q", req.Op)}
+	}
+}
+
+// remove drops the named entry and stops the agent if it became empty, must be called while holding the lock
+func (s *server) remove(name string) {
+	if _, found := s.entries[name]; !found {
+		return
+	}
+
+	s.drop(name)
+
+	if s.exitWhenEmpty && len(s.entries) == 0 {
+		s.stop()
+	}
+}
+
+// drop wipes and deletes the named entry, must be called while holding the lock
+func (s *server) drop(name string) {
+	entry, found := s.entries[name]
+	if !found {
+		return
+	}
+
+	entry.timer.Stop()
+	wipe(entry.secret)
+	delete(s.entries, name)
+}
+
+func (s *server) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.entries {
+		s.drop(name)
+	}
+}
+
+func (s *server) stop() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func wipe(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
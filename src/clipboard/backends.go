@@ -0,0 +1,120 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// backend reads and writes the system clipboard
+type backend interface {
+	name() string
+	available() bool
+	write(text string) error
+
+	// read returns the clipboard content, canRead is false if the backend cannot read the clipboard
+	read() (content string, canRead bool, err error)
+}
+
+// All supported backends, in the order they are tried when none is configured
+var backends = []backend{
+	commandBackend{"wl-copy", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}, "WAYLAND_DISPLAY", ""},
+	commandBackend{"xclip", []string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}, "DISPLAY", ""},
+	commandBackend{"xsel", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}, "DISPLAY", ""},
+	commandBackend{"pbcopy", []string{"pbcopy"}, []string{"pbpaste"}, "", "darwin"},
+	osc52Backend{},
+}
+
+// commandBackend uses external commands that take the content on stdin and print it on stdout
+type commandBackend struct {
+	backendName  string
+	copyCommand  []string
+	pasteCommand []string
+
+	// Environment variable that must be set for the backend to work, e.g. the display server
+	requiredEnv string
+	// Operating system the backend is restricted to
+	requiredOS string
+}
+
+func (b commandBackend) name() string { return b.backendName }
+
+func (b commandBackend) available() bool {
+	if b.requiredOS != "" && runtime.GOOS != b.requiredOS {
+		return false
+	}
+
+	if b.requiredEnv != "" && os.Getenv(b.requiredEnv) == "" {
+		return false
+	}
+
+	_, err := exec.LookPath(b.copyCommand[0])
+	return err == nil
+}
+
+func (b commandBackend) write(text string) error {
+	copyCmd := exec.Command(b.copyCommand[0], b.copyCommand[1:]...)
+	copyCmd.Stdin = strings.NewReader(text)
+
+	var stderr bytes.Buffer
+	copyCmd.Stderr = &stderr
+
+	if err := copyCmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %v %s", b.backendName, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func (b commandBackend) read() (string, bool, error) {
+	output, err := exec.Command(b.pasteCommand[0], b.pasteCommand[1:]...).Output()
+	if err != nil {
+		return "", true, err
+	}
+
+	return string(output), true, nil
+}
+
+// osc52Backend asks the terminal to set the clipboard with an OSC 52 escape sequence, which also works over SSH.
+// Terminals do not reliably allow reading the clipboard back.
+type osc52Backend struct{}
+
+func (osc52Backend) name() string { return "osc52" }
+
+func (osc52Backend) available() bool {
+	tty, err := openTerminal()
+	if err != nil {
+		return false
+	}
+
+	_ = tty.Close()
+	return true
+}
+
+func (osc52Backend) write(text string) error {
+	tty, err := openTerminal()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tty.Close() }()
+
+	_, err = fmt.Fprintf(tty, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+func (osc52Backend) read() (string, bool, error) {
+	return "", false, nil
+}
+
+func openTerminal() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	}
+
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
@@ -0,0 +1,141 @@
+// Package clipboard copies values to the system clipboard, and clears them after a while if they were not replaced
+package clipboard
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// clearRequest is sent to the background process that clears the clipboard.
+// It carries a salted hash of the copied value, so the value itself never leaves this process.
+type clearRequest struct {
+	Backend string `json:"backend"`
+	Salt    []byte `json:"salt"`
+	Hash    []byte `json:"hash"`
+}
+
+// Copy writes text to the clipboard using the configured backend, or the first available one.
+// Returns the name of the backend that was used.
+func Copy(text string) (string, error) {
+	clipboard, err := findBackend(common.ReadConfig().Clipboard)
+	if err != nil {
+		return "", err
+	}
+
+	return clipboard.name(), clipboard.write(text)
+}
+
+// ClearAfter starts a background process that clears the clipboard after the given duration,
+// unless it no longer holds text by then
+func ClearAfter(backendName string, text string, after time.Duration) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(clearRequest{Backend: backendName, Salt: salt, Hash: hash(salt, text)})
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// The payload is small enough to fit in the pipe's buffer, so it's written before the process starts reading
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	if _, err := writer.Write(payload); err != nil {
+		_ = writer.Close()
+		return err
+	}
+
+	_ = writer.Close()
+
+	clearCmd := exec.Command(executable, "clipboard-clear", "--after", after.String())
+	clearCmd.Stdin = reader
+
+	if err := clearCmd.Start(); err != nil {
+		return err
+	}
+
+	// Do not wait for the process, it outlives this one
+	return clearCmd.Process.Release()
+}
+
+// ServeClear reads a request written by ClearAfter from input, waits for the given duration,
+// then clears the clipboard if it still holds the copied value
+func ServeClear(input io.Reader, after time.Duration) error {
+	var req clearRequest
+	if err := json.NewDecoder(input).Decode(&req); err != nil {
+		return err
+	}
+
+	// Keep waiting if the terminal that started us is closed
+	signal.Ignore(syscall.SIGHUP)
+
+	time.Sleep(after)
+
+	clipboard, err := findBackend(req.Backend)
+	if err != nil {
+		return err
+	}
+
+	content, canRead, err := clipboard.read()
+	if err != nil {
+		return err
+	}
+
+	// Backends that cannot read the clipboard are cleared unconditionally
+	if canRead && !bytes.Equal(hash(req.Salt, content), req.Hash) {
+		return nil
+	}
+
+	return clipboard.write("")
+}
+
+// findBackend returns the backend with given name, or the first available backend if name is empty or "auto"
+func findBackend(name string) (backend, error) {
+	for _, clipboard := range backends {
+		if name == "" || name == "auto" {
+			if clipboard.available() {
+				return clipboard, nil
+			}
+
+			continue
+		}
+
+		if clipboard.name() == name {
+			return clipboard, nil
+		}
+	}
+
+	if name == "" || name == "auto" {
+		return nil, errors.New("no clipboard available, install wl-clipboard, xclip or xsel, or use a terminal that supports OSC 52")
+	}
+
+	return nil, fmt.Errorf("unknown clipboard %q", name)
+}
+
+func hash(salt []byte, text string) []byte {
+	sum := sha256.Sum256(append(append([]byte(nil), salt...), text...))
+	return sum[:]
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// agentLockCmd represents the agent lock command
+var agentLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Wipe all cached keys, including unlocked vaults",
+	Long: `Wipe all cached keys from the agent's memory, including the keys of unlocked vaults.
+
+The agent keeps running, so keys unlocked afterwards are cached again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := agent.Clear()
+		if err != nil {
+			common.Fail("Could not lock agent: %v", err)
+		}
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentLockCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+var agentServeFlags = struct {
+	timeout       time.Duration
+	exitWhenEmpty bool
+}{}
+
+// agentServeCmd represents the agent serve command
+var agentServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the agent in the foreground",
+	Long: `Run the agent in the foreground, listening on a unix socket next to the database that only the current user can access.
+
+Secrets are only kept in memory and are wiped once they expire or the agent stops.
+Use 'kv agent start' to run the agent in the background instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := agent.Serve(agentServeFlags.timeout, agentServeFlags.exitWhenEmpty)
+		if err != nil {
+			common.Fail("Agent failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentServeCmd)
+
+	agentServeCmd.Flags().DurationVarP(&agentServeFlags.timeout, "timeout", "t", agent.DefaultTimeout, "How long cached keys are kept")
+	agentServeCmd.Flags().BoolVar(&agentServeFlags.exitWhenEmpty, "exit-when-empty", false, "Exit once no secrets are held")
+}
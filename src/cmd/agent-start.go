@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+var agentStartFlags = struct{ timeout time.Duration }{}
+
+// agentStartCmd represents the agent start command
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent in the background",
+	Long: `Start the agent in the background. The agent keeps running until stopped with 'kv agent stop'.
+
+Cached keys are kept for the duration given by --timeout.`,
+	Example: `  # Start the agent
+  kv agent start
+
+  # Start the agent, keeping cached keys for an hour
+  kv agent start --timeout 1h
+
+  # Enter the password once, then read the key without it
+  kv get github-token --password
+  kv get github-token`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if agent.IsRunning() {
+			common.Fail("Agent is already running at %q", agent.SocketPath())
+		}
+
+		if agentStartFlags.timeout <= 0 {
+			common.Fail("Timeout must be positive, got %v", agentStartFlags.timeout)
+		}
+
+		err := agent.Start(agentStartFlags.timeout, false)
+		if err != nil {
+			common.Fail("Could not start agent: %v", err)
+		}
+
+		common.Stdout.Println("Agent started")
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentStartCmd)
+
+	agentStartCmd.Flags().DurationVarP(&agentStartFlags.timeout, "timeout", "t", agent.DefaultTimeout, "How long cached keys are kept")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// agentStatusCmd represents the agent status command
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check whether the agent is running",
+	Long:  `Check whether the agent is running and how many keys it holds. Exits with an error if the agent is not running.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !agent.IsRunning() {
+			common.Fail("Agent is not running")
+		}
+
+		count, err := agent.Count()
+		common.FailOn(err)
+
+		common.Stdout.Printf("Agent is running at %q, holding %d cached key(s)\n", agent.SocketPath(), count)
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentStatusCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// agentStopCmd represents the agent stop command
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Wipe all cached keys and stop the agent",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !agent.IsRunning() {
+			common.Fail("Agent is not running")
+		}
+
+		err := agent.Stop()
+		if err != nil {
+			common.Fail("Could not stop agent: %v", err)
+		}
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentStopCmd)
+}
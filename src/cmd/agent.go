@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent [command]",
+	Short: "Background agent that caches unlocked secrets",
+	Long: `Background agent that caches derived keys in memory, similar to ssh-agent.
+
+While the agent is running, a successful password entry for a locked key caches the key derived from that password,
+so that following 'get' and 'unlock' calls on the same key do not ask for the password again until the cached key expires.
+Only derived keys are cached, never passwords, and they are wiped from memory once they expire or the agent is locked.
+
+The agent listens on a unix socket next to the database that only the current user can access.
+Set KV_AGENT_SOCK to use a different socket path.`,
+	GroupID: "security",
+}
+
+// derivedKeyEntry is the agent entry holding the derived key for a locked key
+func derivedKeyEntry(key string) string {
+	return "key:" + key
+}
+
+// decryptWithCachedKey decrypts the locked value of key using the derived key cached in the agent, if any
+func decryptWithCachedKey(key string, value string) (string, bool) {
+	derivedKey, found := agent.Get(derivedKeyEntry(key))
+	if !found {
+		return "", false
+	}
+
+	plaintext, err := common.DecryptWithDerivedKey(value, derivedKey)
+	if err != nil {
+		return "", false
+	}
+
+	return plaintext, true
+}
+
+// cacheDerivedKey caches the key derived from password for the locked value of key, if the agent is running
+func cacheDerivedKey(key string, value string, password string) {
+	if !agent.IsRunning() {
+		return
+	}
+
+	derivedKey, err := common.DeriveKeyFor(value, password)
+	if err != nil {
+		return
+	}
+
+	_ = agent.Put(derivedKeyEntry(key), derivedKey, 0)
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+}
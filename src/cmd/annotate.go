@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var annotateFlags = struct {
+	description string
+	tags        []string
+	untags      []string
+}{}
+
+// annotateCmd represents the annotate command
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <key>",
+	Short: "Set the description and tags of a key",
+	Long: `Set the description and tags of a key, without changing its value.
+
+Descriptions and tags are stored apart from values, so changing them does not add history records.
+They are shown by 'kv list' and 'kv stat', and dropped when the key is deleted or expires.
+Keys can be selected by tag with --tag in 'kv list', 'kv lock', 'kv delete' and 'kv expire'.
+Descriptions and tags can also be given when setting a value, see 'kv set --desc --tag'.
+
+Tags cannot contain spaces or commas, an empty --desc removes the description.`,
+	Example: `  # Describe a key
+  kv annotate db.pass --desc "prod RDS master"
+
+  # Tag a key
+  kv annotate db.pass --tag prod --tag db
+
+  # Remove a tag
+  kv annotate db.pass --untag db`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		if !cmd.Flags().Changed("desc") && len(annotateFlags.tags) == 0 && len(annotateFlags.untags) == 0 {
+			common.Fail("Nothing to change, pass --desc, --tag or --untag")
+		}
+
+		validateTags(annotateFlags.tags)
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			if services.GetItem(tx, key) == nil {
+				common.Fail("Key %q does not exist", key)
+			}
+
+			if cmd.Flags().Changed("desc") {
+				services.SetDescription(tx, key, annotateFlags.description)
+			}
+
+			services.RemoveTags(tx, key, annotateFlags.untags...)
+			services.AddTags(tx, key, annotateFlags.tags...)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.Flags().StringVar(&annotateFlags.description, "desc", "", "Description of the key, empty to remove it")
+	addTagFlag(annotateCmd, &annotateFlags.tags, "tag", "Add given tag to the key, can be repeated")
+	addTagFlag(annotateCmd, &annotateFlags.untags, "untag", "Remove given tag from the key, can be repeated")
+}
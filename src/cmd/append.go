@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var appendFlags = struct {
+	newline bool
+}{}
+
+// appendCmd represents the append command
+var appendCmd = &cobra.Command{
+	Use:   "append <key> [text]",
+	Short: "Append text to the value of a key",
+	Long: `Append text to the value of a key and print the new value. If no text is provided, reads from stdin.
+
+The value is read and written in a single transaction, so concurrent appends are never lost.
+Keys that do not exist are created. The expiry of the key is kept, and the change is recorded in history like any other value.
+Locked keys and keys inside a vault cannot be appended to.`,
+	Example: `  # Append to a value
+  kv append greeting ", world"
+
+  # Append a line to a log kept in a key
+  echo "deployed v1.2.0" | kv append deploy.log --newline`,
+	GroupID: "kv",
+	Args:    cobra.RangeArgs(1, 2),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		var text string
+		if len(args) == 2 {
+			text = args[1]
+		} else {
+			stdin, err := io.ReadAll(os.Stdin)
+			common.FailOn(err)
+
+			text = strings.TrimRight(string(stdin), "\r\n")
+		}
+
+		if text == "" {
+			common.Fail("No text provided")
+		}
+
+		separator := ""
+		if appendFlags.newline {
+			separator = "\n"
+		}
+
+		var value string
+		services.RunInTransaction(func(tx *sql.Tx) {
+			key = resolveLinkedKey(tx, key)
+			value = services.AppendValue(tx, key, text, separator)
+		})
+
+		common.Stdout.Println(value)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(appendCmd)
+
+	appendCmd.Flags().BoolVarP(&appendFlags.newline, "newline", "n", false, "Separate the text from the current value with a line break")
+}
@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var batchFlags = struct {
+	dryRun bool
+}{}
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch [file|-]",
+	Short: "Apply a list of operations in a single transaction",
+	Long: `Apply a list of operations from a file, or from stdin if no file or - is given, in a single transaction:
+either all operations are applied, or none of them is if any fails.
+
+Operations are given one per line, with arguments quoted like in a shell. Blank lines and lines starting with # are ignored:
+  set <key> <value> [ttl]
+  delete <key>
+  expire <key> <ttl|never>
+  lock <key>
+  rename <key> <new-key>
+  copy <key> <new-key>
+
+Or as a JSON array of operations, using the fields op, key, value, ttl and to, e.g.
+  [{"op": "set", "key": "a", "value": "1", "ttl": "1h"}, {"op": "rename", "key": "a", "to": "b"}]
+
+Durations accept d (day) and w (week) units in addition to h, m and s.
+Lock operations encrypt with a single password, given like with 'kv lock'.
+With --dry-run, the changes each key would go through are printed, and nothing is applied.`,
+	Example: `  # Apply operations from a file
+  kv batch changes.txt
+
+  # Preview what the operations would change
+  kv batch changes.txt --dry-run
+
+  # Apply operations from stdin
+  printf 'set app.port 9090\ndelete app.legacy-port\n' | kv batch`,
+	GroupID: "kv",
+	Args:    cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var input []byte
+		var err error
+		if len(args) == 0 || args[0] == "-" {
+			input, err = io.ReadAll(os.Stdin)
+		} else {
+			input, err = os.ReadFile(common.NormalizePath(args[0]))
+		}
+
+		if err != nil {
+			common.Fail("Could not read operations: %v", err)
+		}
+
+		operations, err := common.ParseBatch(string(input))
+		if err != nil {
+			common.Fail("Invalid operations: %v", err)
+		}
+
+		if len(operations) == 0 {
+			common.Fail("No operations provided")
+		}
+
+		// Keys that may change, in the order they are first used
+		var keys []string
+		for _, operation := range operations {
+			for _, key := range []string{operation.Key, operation.To} {
+				if key != "" && !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		batch := newBatchApplier(cmd, operations, batchFlags.dryRun)
+
+		if !batchFlags.dryRun {
+			services.RunInTransaction(func(tx *sql.Tx) {
+				for _, operation := range operations {
+					batch.apply(tx, operation)
+				}
+			})
+
+			return
+		}
+
+		services.RunInDryTransaction(func(tx *sql.Tx) {
+			before := snapshotItems(tx, keys)
+			for _, operation := range operations {
+				batch.apply(tx, operation)
+			}
+
+			printItemChanges(keys, before, snapshotItems(tx, keys))
+		})
+	},
+}
+
+// batchApplier applies batch operations, with the credentials they need read beforehand
+type batchApplier struct {
+	now       time.Time
+	encrypt   func(value string) (string, error)
+	vaultKeys map[string][]byte
+
+	// dryRun is set when changes are rolled back, so values are not encrypted
+	dryRun bool
+
+	// password locks set values outside vaults, if given
+	password string
+}
+
+// newBatchApplier reads the credentials needed by operations before their transaction starts,
+// values are not encrypted in dry runs
+func newBatchApplier(cmd *cobra.Command, operations []common.BatchOperation, dryRun bool) batchApplier {
+	batch := batchApplier{now: time.Now(), vaultKeys: map[string][]byte{}, dryRun: dryRun}
+
+	if dryRun {
+		batch.encrypt = func(value string) (string, error) { return value, nil }
+		return batch
+	}
+
+	if slices.ContainsFunc(operations, func(operation common.BatchOperation) bool { return operation.Op == "lock" }) {
+		batch.encrypt = lockEncryption(cmd)
+	}
+
+	var vaults []services.Vault
+	services.RunInTransaction(func(tx *sql.Tx) {
+		for _, operation := range operations {
+			if vault := services.FindVault(tx, operation.Key); operation.Op == "set" && vault != nil {
+				vaults = append(vaults, *vault)
+			}
+		}
+	})
+
+	for _, vault := range vaults {
+		if _, found := batch.vaultKeys[vault.Prefix]; !found {
+			batch.vaultKeys[vault.Prefix] = readVaultKey(cmd, vault)
+		}
+	}
+
+	return batch
+}
+
+func (batch batchApplier) apply(tx *sql.Tx, operation common.BatchOperation) {
+	key := operation.Key
+
+	switch operation.Op {
+	case "set":
+		value, valueType := operation.Value, ""
+		if item := services.GetItem(tx, key); item != nil {
+			valueType = item.Type
+		}
+
+		if err := common.ValidateValue(valueType, value); err != nil {
+			common.Fail("%s: invalid %s value: %v", operation.Position, valueType, err)
+		}
+
+		services.EnsureSchemas(tx, key, valueType, value)
+
+		isLocked := false
+		if vault := services.FindVault(tx, key); vault != nil {
+			vaultKey, found := batch.vaultKeys[vault.Prefix]
+			switch {
+			case found:
+				var err error
+				value, err = common.EncryptWithKey(value, vaultKey)
+				common.FailOn(err)
+			case !batch.dryRun:
+				// The vault was created after its key was read, the value must never be stored as plain text
+				common.Fail("%s: key %q belongs to vault %q that was created meanwhile, try again", operation.Position, key, vault.Prefix)
+			}
+
+			isLocked = true
+		} else if batch.password != "" {
+			var err error
+			value, err = common.Encrypt(value, batch.password)
+			common.FailOn(err)
+
+			isLocked = true
+		}
+
+		services.SetValue(tx, key, value, operation.ExpiresAt(batch.now), isLocked)
+	case "delete":
+		if services.GetItem(tx, key) == nil {
+			common.Fail("%s: key %q does not exist", operation.Position, key)
+		}
+
+		services.SetValue(tx, key, "", nil, false)
+	case "expire":
+		item := services.GetItem(tx, key)
+		if item == nil {
+			common.Fail("%s: key %q does not exist", operation.Position, key)
+			return // To shut up the compiler
+		}
+
+		services.SetValue(tx, key, item.Value, operation.ExpiresAt(batch.now), item.IsLocked)
+	case "lock":
+		services.LockKey(tx, key, batch.encrypt)
+	case "rename":
+		renameKey(tx, key, operation.To)
+	case "copy":
+		copyKey(tx, key, operation.To)
+	}
+}
+
+// snapshotItems returns the current items of keys, keys that do not exist are left out
+func snapshotItems(tx *sql.Tx, keys []string) map[string]services.KVItem {
+	items := map[string]services.KVItem{}
+	for _, key := range keys {
+		if item := services.GetItem(tx, key); item != nil {
+			items[key] = *item
+		}
+	}
+
+	return items
+}
+
+// printItemChanges prints how each of keys changed between before and after, without revealing locked or hidden values
+func printItemChanges(keys []string, before map[string]services.KVItem, after map[string]services.KVItem) {
+	added, removed, changed := color.New(color.FgGreen), color.New(color.FgRed), color.New(color.FgYellow)
+
+	display := func(item services.KVItem) string {
+		switch {
+		case item.IsLocked:
+			return "[Locked]"
+		case item.IsHidden:
+			return "[Hidden]"
+		case item.IsLink:
+			return "-> " + item.Value
+		default:
+			return item.Value
+		}
+	}
+
+	expiry := func(item services.KVItem) string {
+		if item.ExpiresAt == nil {
+			return "never expires"
+		}
+
+		return "expires at " + item.ExpiresAt.Local().Format(time.DateTime)
+	}
+
+	changes := 0
+	for _, key := range keys {
+		oldItem, existed := before[key]
+		newItem, exists := after[key]
+
+		switch {
+		case !existed && !exists:
+			continue
+		case !existed:
+			common.Stdout.Println(added.Sprintf("+ %s = %s", key, display(newItem)))
+			if newItem.ExpiresAt != nil {
+				common.Stdout.Println(added.Sprintf("+ %s %s", key, expiry(newItem)))
+			}
+		case !exists:
+			common.Stdout.Println(removed.Sprintf("- %s", key))
+		default:
+			if oldItem.Value == newItem.Value && display(oldItem) == display(newItem) && expiry(oldItem) == expiry(newItem) {
+				continue
+			}
+
+			if oldItem.Value != newItem.Value || display(oldItem) != display(newItem) {
+				common.Stdout.Println(changed.Sprintf("~ %s = %s -> %s", key, display(oldItem), display(newItem)))
+			}
+
+			if expiry(oldItem) != expiry(newItem) {
+				common.Stdout.Println(changed.Sprintf("~ %s %s", key, expiry(newItem)))
+			}
+		}
+
+		changes++
+	}
+
+	if changes == 0 {
+		common.Stderr.Println("No changes.")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().BoolVar(&batchFlags.dryRun, "dry-run", false, "Print what would change without applying anything")
+	addPasswordFlags(batchCmd, "Password to lock keys with, for lock operations")
+}
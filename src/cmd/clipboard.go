@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/AmrSaber/kv/src/clipboard"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+var clipboardClearFlags = struct{ after time.Duration }{}
+
+// clipboardClearCmd is started in the background by commands that copy values to the clipboard
+var clipboardClearCmd = &cobra.Command{
+	Use:    "clipboard-clear",
+	Short:  "Clear the clipboard after a while if it still holds a copied value",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := clipboard.ServeClear(os.Stdin, clipboardClearFlags.after)
+		if err != nil {
+			common.Fail("Could not clear clipboard: %v", err)
+		}
+	},
+}
+
+// addClipFlags defines --clip and --clear-after flags on cmd
+func addClipFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("clip", false, "Copy the value to the clipboard instead of printing it")
+	cmd.Flags().Duration("clear-after", 30*time.Second, "With --clip, clear the clipboard after this duration if it still holds the value, 0 to keep it")
+}
+
+// clipRequested reports whether --clip was passed to cmd
+func clipRequested(cmd *cobra.Command) bool {
+	clip, _ := cmd.Flags().GetBool("clip")
+	return clip
+}
+
+// copyToClipboard copies value to the clipboard and schedules clearing it according to cmd's --clear-after flag
+func copyToClipboard(cmd *cobra.Command, value string) {
+	clearAfter, _ := cmd.Flags().GetDuration("clear-after")
+	if clearAfter < 0 {
+		common.Fail("Clear duration cannot be negative, got %v", clearAfter)
+	}
+
+	backendName, err := clipboard.Copy(value)
+	if err != nil {
+		common.Fail("Could not copy to clipboard: %v", err)
+	}
+
+	if clearAfter == 0 {
+		common.Stderr.Println("Copied to clipboard")
+		return
+	}
+
+	if err := clipboard.ClearAfter(backendName, value, clearAfter); err != nil {
+		common.Fail("Could not schedule clearing the clipboard: %v", err)
+	}
+
+	common.Stderr.Printf("Copied to clipboard, it will be cleared in %v\n", clearAfter)
+}
+
+func init() {
+	rootCmd.AddCommand(clipboardClearCmd)
+
+	clipboardClearCmd.Flags().DurationVar(&clipboardClearFlags.after, "after", 30*time.Second, "How long to wait before clearing")
+}
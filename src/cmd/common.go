@@ -3,7 +3,12 @@ package cmd
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode"
 
 	"github.com/AmrSaber/kv/src/common"
 	"github.com/AmrSaber/kv/src/services"
@@ -27,9 +32,76 @@ func completeKeyArg(toComplete string, matchType services.MatchType) ([]cobra.Co
 	return []cobra.Completion(matchingKeys), cobra.ShellCompDirectiveNoFileComp
 }
 
-// readPassword returns the password for cmd's --password flag.
-// If provided with a value, returns it directly.
-// If provided without a value (bare --password), prompts interactively with hidden input.
+// selectKeys returns the keys targeted by a command's arguments and its --all and --prefix flags
+func selectKeys(tx *sql.Tx, args []string, all bool, prefix bool) []string {
+	if all {
+		return services.ListKeys(tx, "", services.MatchExisting)
+	}
+
+	if prefix {
+		return services.ListKeys(tx, args[0], services.MatchExisting)
+	}
+
+	return args
+}
+
+// addTagFlag defines a repeatable flag on cmd that takes tags, completed with the tags in use
+func addTagFlag(cmd *cobra.Command, tags *[]string, name string, usage string) {
+	cmd.Flags().StringArrayVar(tags, name, nil, usage)
+	_ = cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		var tags []string
+		services.RunInTransaction(func(tx *sql.Tx) {
+			tags = services.ListTags(tx)
+		})
+
+		return []cobra.Completion(tags), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// validateTags fails unless every tag is non-empty and free of whitespace and commas
+func validateTags(tags []string) {
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+			common.Fail("Invalid tag %q, tags cannot be empty or contain spaces or commas", tag)
+		}
+	}
+}
+
+// passwordEnvVar is the environment variable consulted for passwords when no password flag is given
+const passwordEnvVar = "KV_PASSWORD"
+
+// addPasswordFlags defines --password, --password-file and --password-fd flags on cmd
+func addPasswordFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringP("password", "p", "", usage)
+	cmd.Flags().Lookup("password").NoOptDefVal = passwordPromptSentinel
+
+	cmd.Flags().String("password-file", "", "Read password from the first line of given file")
+	cmd.Flags().Int("password-fd", -1, "Read password from given file descriptor")
+
+	cmd.MarkFlagsMutuallyExclusive("password", "password-file", "password-fd")
+}
+
+// passwordRequested reports whether any of cmd's password flags was passed
+func passwordRequested(cmd *cobra.Command) bool {
+	flags := cmd.Flags()
+	return flags.Changed("password") || flags.Changed("password-file") || flags.Changed("password-fd")
+}
+
+// passwordAvailable reports whether readPassword can get a password without prompting,
+// or the user explicitly asked to be prompted
+func passwordAvailable(cmd *cobra.Command) bool {
+	return passwordRequested(cmd) || os.Getenv(passwordEnvVar) != "" || common.ReadConfig().PasswordCommand != ""
+}
+
+// readPassword returns the password for cmd, from the first available source of:
+//   - --password flag with a value
+//   - bare --password flag, which prompts interactively with hidden input
+//   - --password-file flag
+//   - --password-fd flag
+//   - KV_PASSWORD environment variable
+//   - password-command from config
+//   - interactive prompt
+//
 // When confirm is true (write operations), the prompt is shown twice and values must match.
 func readPassword(cmd *cobra.Command, confirm bool) string {
 	flag := cmd.Flags().Lookup("password")
@@ -37,15 +109,79 @@ func readPassword(cmd *cobra.Command, confirm bool) string {
 		panic("--password flag not defined")
 	}
 
-	// Sentinel means bare --password was passed; fall through to prompt
+	// Sentinel means bare --password was passed; prompt explicitly
 	val := flag.Value.String()
-	if val != "" && val != passwordPromptSentinel {
+	if val == passwordPromptSentinel {
+		return promptPassword(confirm)
+	}
+
+	if val != "" {
 		return val
 	}
 
+	if path, _ := cmd.Flags().GetString("password-file"); path != "" {
+		content, err := os.ReadFile(common.NormalizePath(path))
+		if err != nil {
+			common.Fail("Could not read password file: %v", err)
+		}
+
+		return firstLine(content)
+	}
+
+	if fd, _ := cmd.Flags().GetInt("password-fd"); fd >= 0 {
+		file := os.NewFile(uintptr(fd), "password-fd")
+		if file == nil {
+			common.Fail("Invalid password file descriptor %d", fd)
+		}
+
+		defer func() { _ = file.Close() }()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			common.Fail("Could not read password from file descriptor %d: %v", fd, err)
+		}
+
+		return firstLine(content)
+	}
+
+	if password := os.Getenv(passwordEnvVar); password != "" {
+		return password
+	}
+
+	if passwordCommand := common.ReadConfig().PasswordCommand; passwordCommand != "" {
+		return runPasswordCommand(passwordCommand)
+	}
+
 	return promptPassword(confirm)
 }
 
+// runPasswordCommand runs command in the system shell and returns the first line of its output as the password
+func runPasswordCommand(command string) string {
+	var shellCmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		shellCmd = exec.Command("cmd", "/C", command)
+	} else {
+		shellCmd = exec.Command("sh", "-c", command)
+	}
+
+	// Let the command interact with the user if it needs to, e.g. to unlock a keychain
+	shellCmd.Stdin = os.Stdin
+	shellCmd.Stderr = os.Stderr
+
+	output, err := shellCmd.Output()
+	if err != nil {
+		common.Fail("Password command failed: %v", err)
+	}
+
+	return firstLine(output)
+}
+
+// firstLine returns content up to the first line break
+func firstLine(content []byte) string {
+	line, _, _ := strings.Cut(string(content), "\n")
+	return strings.TrimSuffix(line, "\r")
+}
+
 func promptPassword(confirm bool) string {
 	password := readPasswordFromTerminal("Password")
 
@@ -16,7 +16,9 @@ var copyCmd = &cobra.Command{
 
 The copy operation copies the current value and encryption status from the source key.
 TTL is not copied - the destination key will have no expiration unless you set it separately.
-If the destination key already exists, it will be updated (creating a new history entry).`,
+If the destination key already exists, it will be updated (creating a new history entry).
+Links are copied as links to the same target, use 'kv get' and 'kv set' to copy the value they resolve to instead.
+Plain values must validate against the schemas of the destination key, see 'kv schema'.`,
 	Example: `  # Copy a key
   kv copy api-key api-key-backup
 
@@ -37,22 +39,45 @@ If the destination key already exists, it will be updated (creating a new histor
 		toKey := args[1]
 
 		services.RunInTransaction(func(tx *sql.Tx) {
-			// Get the source item
-			fromItem := services.GetItem(tx, fromKey)
-			if fromItem == nil {
-				common.Fail("Key %q does not exist", fromKey)
-				panic("Unreachable") // To suppress compiler warnings
-			}
-
-			// Copy to destination (without TTL)
-			services.SetValue(tx, toKey, fromItem.Value, nil, fromItem.IsLocked)
-			if fromItem.IsHidden {
-				services.HideKey(tx, toKey)
-			}
+			copyKey(tx, fromKey, toKey)
 		})
 	},
 }
 
+// copyKey copies the value, type and hidden state of fromKey to toKey, without TTL; links are copied as links
+func copyKey(tx *sql.Tx, fromKey string, toKey string) {
+	// Get the source item
+	fromItem := services.GetItem(tx, fromKey)
+	if fromItem == nil {
+		common.Fail("Key %q does not exist", fromKey)
+		panic("Unreachable") // To suppress compiler warnings
+	}
+
+	services.EnsureSameVault(tx, fromKey, toKey)
+
+	// Links are copied as links to the same target
+	if fromItem.IsLink {
+		services.SetLink(tx, toKey, fromItem.Value)
+		if fromItem.IsHidden {
+			services.HideKey(tx, toKey)
+		}
+
+		return
+	}
+
+	// Locked values cannot be checked against schemas
+	if !fromItem.IsLocked {
+		services.EnsureSchemas(tx, toKey, fromItem.Type, fromItem.Value)
+	}
+
+	// Copy to destination (without TTL)
+	services.SetValue(tx, toKey, fromItem.Value, nil, fromItem.IsLocked)
+	services.SetValueType(tx, toKey, fromItem.Type)
+	if fromItem.IsHidden {
+		services.HideKey(tx, toKey)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(copyCmd)
 }
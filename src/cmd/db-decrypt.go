@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// dbDecryptCmd represents the db decrypt command
+var dbDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Store the database in plain text again",
+	Long: `Turn off encryption at rest, storing the database file in plain text again.
+
+Individually locked values stay encrypted.`,
+	Example: `  # Decrypt the database
+  kv db decrypt`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !common.IsDBEncrypted() {
+			common.Fail("Database is not encrypted")
+		}
+
+		err := common.DecryptDB()
+		if err != nil {
+			common.Fail("Could not decrypt database: %v", err)
+		}
+
+		_ = agent.Delete(dbAgentEntry())
+
+		common.Stdout.Println("Database decrypted")
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbDecryptCmd)
+}
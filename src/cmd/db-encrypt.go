@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// dbEncryptCmd represents the db encrypt command
+var dbEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the whole database at rest",
+	Long: `Encrypt the whole database file, including key names, hidden values, timestamps and history.
+
+The database is encrypted with a random master key, which is itself encrypted with the given password.
+While encrypted, the database is decrypted in memory for each command and written back encrypted, it never
+touches the disk in plain text.
+
+The master key is needed by every command. Unlock it once per session with 'kv db unlock',
+or provide the password with KV_DB_PASSWORD environment variable, otherwise it's asked for interactively.
+
+Backups of an encrypted database are encrypted as well. Backups created before encrypting are not,
+and plain-text remnants of the old database file might remain on disk, remove or wipe them as needed.`,
+	Example: `  # Encrypt the database, enter password interactively
+  kv db encrypt
+
+  # Unlock it for the session
+  kv db unlock
+
+  # Use it in a script
+  KV_DB_PASSWORD=mypass kv get api-key`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if common.IsDBEncrypted() {
+			common.Fail("Database is already encrypted")
+		}
+
+		password := readPassword(cmd, true)
+		if password == "" {
+			common.Fail("Password cannot be empty")
+		}
+
+		key, err := common.EncryptDB(password)
+		if err != nil {
+			common.Fail("Could not encrypt database: %v", err)
+		}
+
+		if agent.IsRunning() {
+			_ = agent.Put(dbAgentEntry(), key, 0)
+		}
+
+		common.Stdout.Println("Database encrypted")
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbEncryptCmd)
+
+	addPasswordFlags(dbEncryptCmd, "Database password")
+}
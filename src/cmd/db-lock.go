@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+// dbLockCmd represents the db lock command
+var dbLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the encrypted database",
+	Long:  `Drop the cached master key of the encrypted database, so the password is needed again.`,
+	Example: `  # Lock the database
+  kv db lock`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := agent.Delete(dbAgentEntry())
+		if err != nil {
+			common.Fail("Could not lock database: %v", err)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbLockCmd)
+}
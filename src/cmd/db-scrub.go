@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// dbScrubCmd represents the db scrub command
+var dbScrubCmd = &cobra.Command{
+	Use:   "scrub",
+	Short: "Remove plain-text history of locked keys",
+	Long: `Remove plain-text values from the history of locked keys, left there by locking keys that had older values.
+
+By default these history records are deleted. If a password is given, they are encrypted with it instead,
+except for keys in vaults, whose plain-text history is always deleted.
+
+The database is then compacted and its WAL file emptied, so that the removed values are no longer on disk.`,
+	Example: `  # Delete plain-text history of locked keys
+  kv db scrub
+
+  # Encrypt plain-text history of locked keys instead
+  kv db scrub --password`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var encrypt func(value string) (string, error)
+		if passwordRequested(cmd) {
+			password := readPassword(cmd, true)
+			if password == "" {
+				common.Fail("Password cannot be empty")
+			}
+
+			encrypt = func(value string) (string, error) {
+				return common.Encrypt(value, password)
+			}
+		}
+
+		scrubbed := 0
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, item := range services.ListItems(tx, "", services.MatchExisting) {
+				if !item.IsLocked {
+					continue
+				}
+
+				if services.FindVault(tx, item.Key) != nil {
+					scrubbed += services.ScrubKeyHistory(tx, item.Key, nil)
+				} else {
+					scrubbed += services.ScrubKeyHistory(tx, item.Key, encrypt)
+				}
+			}
+		})
+
+		if err := common.CompactDB(); err != nil {
+			common.Fail("Could not compact database: %v", err)
+		}
+
+		common.Stdout.Printf("Scrubbed %d history record(s)\n", scrubbed)
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbScrubCmd)
+
+	addPasswordFlags(dbScrubCmd, "Encrypt plain-text history with this password instead of deleting it")
+}
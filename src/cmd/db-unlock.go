@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+var dbUnlockFlags = struct{ duration time.Duration }{}
+
+// dbUnlockCmd represents the db unlock command
+var dbUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the encrypted database for a limited time",
+	Long: `Unlock the encrypted database for a limited time, so that commands can use it without a password.
+
+The master key is cached in memory by a background agent that only the current user can access.
+The password itself is never cached. Use 'kv db lock' to drop the key before it expires.`,
+	Example: `  # Unlock the database for 15 minutes
+  kv db unlock
+
+  # Unlock the database for the work day
+  kv db unlock --for 8h`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !common.IsDBEncrypted() {
+			common.Fail("Database is not encrypted")
+		}
+
+		if dbUnlockFlags.duration <= 0 {
+			common.Fail("Duration must be positive, got %v", dbUnlockFlags.duration)
+		}
+
+		encryptedPath := common.GetEncryptedDBPath()
+		wrappedKey, err := common.ReadDBWrappedKey(encryptedPath)
+		if err != nil {
+			common.Fail("Could not read database: %v", err)
+		}
+
+		key, err := common.UnwrapMasterKey(wrappedKey, readPassword(cmd, false))
+		if err != nil || !common.VerifyDBMasterKey(encryptedPath, key) {
+			common.Fail("Wrong password")
+		}
+
+		err = agent.EnsureRunning()
+		if err != nil {
+			common.Fail("Could not start agent: %v", err)
+		}
+
+		err = agent.Put(dbAgentEntry(), key, dbUnlockFlags.duration)
+		if err != nil {
+			common.Fail("Could not cache database key: %v", err)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbUnlockCmd)
+
+	dbUnlockCmd.Flags().DurationVar(&dbUnlockFlags.duration, "for", 15*time.Minute, "How long the database stays unlocked")
+	addPasswordFlags(dbUnlockCmd, "Database password")
+}
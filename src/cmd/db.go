@@ -1,6 +1,17 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"errors"
+	"os"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// dbPasswordEnvVar is the environment variable consulted for the password of an encrypted database
+const dbPasswordEnvVar = "KV_DB_PASSWORD"
 
 // dbCmd represents the db command
 var dbCmd = &cobra.Command{
@@ -13,6 +24,42 @@ Note: These commands are not thread-safe. It's the responsibility of the caller
 	`,
 }
 
+// dbAgentEntry is the name under which the master key of the encrypted database is cached in the agent
+func dbAgentEntry() string {
+	return "db:" + common.GetEncryptedDBPath()
+}
+
+// readDBMasterKey returns the master key of the encrypted database, from the agent if it holds it,
+// otherwise from the password in KV_DB_PASSWORD or entered interactively
+func readDBMasterKey(wrappedKey string, verify func(key []byte) bool) ([]byte, error) {
+	if key, found := agent.Get(dbAgentEntry()); found && verify(key) {
+		return key, nil
+	}
+
+	password := os.Getenv(dbPasswordEnvVar)
+	if password == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, common.ErrDBLocked
+		}
+
+		password = readPasswordFromTerminal("Database password")
+	}
+
+	key, err := common.UnwrapMasterKey(wrappedKey, password)
+	if err != nil || !verify(key) {
+		return nil, errors.New("wrong database password")
+	}
+
+	// Keep the key for the session if the agent is already running
+	if agent.IsRunning() {
+		_ = agent.Put(dbAgentEntry(), key, 0)
+	}
+
+	return key, nil
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
+
+	common.MasterKeyProvider = readDBMasterKey
 }
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var decrFlags = struct {
+	by int64
+}{}
+
+// decrCmd represents the decr command
+var decrCmd = &cobra.Command{
+	Use:   "decr <key>",
+	Short: "Decrement the integer value of a key",
+	Long: `Decrement the integer value of a key by 1, or by --by, and print the new value.
+
+Works like 'kv incr': the change is atomic, keys that do not exist start from 0, and the expiry of the key is kept.`,
+	Example: `  # Decrement a counter
+  kv decr quota.remaining
+
+  # Decrement by a given step
+  kv decr quota.remaining --by 5`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		incrementKey(args[0], -decrFlags.by)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(decrCmd)
+
+	decrCmd.Flags().Int64Var(&decrFlags.by, "by", 1, "Amount to subtract")
+}
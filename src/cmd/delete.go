@@ -11,16 +11,18 @@ import (
 var deleteFlags = struct {
 	prefix bool
 	prune  bool
+	tags   []string
 }{}
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:     "delete <key|prefix|key1 key2...>",
+	Use:     "delete <key|prefix|key1 key2...|--tag tag>",
 	Aliases: []string{"del", "rm"},
 	Short:   "Delete a key or keys matching a prefix",
 	Long: `Delete a key or multiple keys matching a prefix.
 
-By default, deletion is soft (keeps history). Use --prune to permanently delete including history.`,
+By default, deletion is soft (keeps history). Use --prune to permanently delete including history.
+With --tag, all keys with given tags are deleted, see 'kv annotate'.`,
 	Example: `  # Delete a single key (soft delete, keeps history)
   kv delete api-key
 
@@ -34,25 +36,46 @@ By default, deletion is soft (keeps history). Use --prune to permanently delete
   kv delete temp --prefix
 
   # Permanently delete all keys with a prefix
-  kv delete cache --prefix --prune`,
+  kv delete cache --prefix --prune
+
+  # Delete all keys tagged both staging and temp
+  kv delete --tag staging --tag temp`,
 	GroupID: "kv",
-	Args:    cobra.MinimumNArgs(1),
+	Args:    cobra.ArbitraryArgs,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
-		if deleteFlags.prefix {
+		if deleteFlags.prefix || len(deleteFlags.tags) > 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
 
 		return completeKeyArg(toComplete, services.MatchExisting)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if deleteFlags.prefix {
+		if len(deleteFlags.tags) > 0 {
+			if len(args) > 0 {
+				common.Fail("Cannot have arguments with --tag")
+			}
+
+			validateTags(deleteFlags.tags)
+		} else if len(args) == 0 {
+			if deleteFlags.prefix {
+				common.Fail("Prefix must be provided")
+			} else {
+				common.Fail("At least one key must be provided")
+			}
+		}
+
+		if deleteFlags.prefix || len(deleteFlags.tags) > 0 {
 			if len(args) > 1 {
 				common.Fail("Cannot use --prefix with multiple keys")
 			}
 
-			key := args[0]
 			services.RunInTransaction(func(tx *sql.Tx) {
-				keys := services.ListKeys(tx, key, services.MatchExisting)
+				var keys []string
+				if deleteFlags.prefix {
+					keys = services.ListKeys(tx, args[0], services.MatchExisting)
+				} else {
+					keys = services.ListTaggedKeys(tx, deleteFlags.tags)
+				}
 
 				for _, key := range keys {
 					services.SetValue(tx, key, "", nil, false)
@@ -89,4 +112,6 @@ func init() {
 
 	deleteCmd.Flags().BoolVar(&deleteFlags.prefix, "prefix", false, "Delete all keys matching given prefix")
 	deleteCmd.Flags().BoolVar(&deleteFlags.prune, "prune", false, "Also delete key(s) history")
+	addTagFlag(deleteCmd, &deleteFlags.tags, "tag", "Delete all keys with given tag, can be repeated")
+	deleteCmd.MarkFlagsMutuallyExclusive("prefix", "tag")
 }
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var doctorSecretsFlags = struct {
+	fix    string
+	output string
+}{}
+
+const (
+	secretIssueExposed      = "exposed"
+	secretIssuePlainHistory = "plain-history"
+)
+
+type secretIssue struct {
+	Key   string `json:"key" yaml:"key"`
+	Issue string `json:"issue" yaml:"issue"`
+	Kind  string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Fixed bool   `json:"fixed" yaml:"fixed"`
+}
+
+// doctorSecretsCmd represents the doctor secrets command
+var doctorSecretsCmd = &cobra.Command{
+	Use:   "secrets [prefix]",
+	Short: "Find secrets that are stored unprotected",
+	Long: `Scan the store for values that look like credentials and are stored unprotected.
+
+Reported issues:
+  - exposed: the value looks like a credential (AWS keys, GitHub tokens, private keys, JWTs, high-entropy strings...)
+    but it's neither locked nor hidden
+  - plain-history: the key is locked, but its history still contains plain-text values
+
+Use --fix to remediate exposed values in bulk, either by hiding them or by locking them with a password.
+Plain-text history can be removed with 'kv db scrub', or with --fix lock which encrypts it along with the value.
+
+Exits with a non-zero status if any issue remains unfixed.`,
+	Example: `  # Scan the whole store
+  kv doctor secrets
+
+  # Scan keys with a prefix
+  kv doctor secrets config.
+
+  # Hide all exposed secrets
+  kv doctor secrets --fix hide
+
+  # Lock all exposed secrets, and encrypt the plain-text history of locked keys
+  kv doctor secrets --fix lock --password`,
+	Args: cobra.MaximumNArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var prefix string
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		fix := doctorSecretsFlags.fix
+		if fix != "" && fix != "hide" && fix != "lock" {
+			common.Fail("Unsupported fix %q, options: hide, lock", fix)
+		}
+
+		var encrypt func(value string) (string, error)
+		if fix == "lock" {
+			password := readPassword(cmd, true)
+			if password == "" {
+				common.Fail("Password cannot be empty")
+			}
+
+			encrypt = func(value string) (string, error) {
+				return common.Encrypt(value, password)
+			}
+		}
+
+		var issues []secretIssue
+		scrubbed := false
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, item := range services.ListItems(tx, prefix, services.MatchExisting) {
+				if item.IsLocked || item.IsLink {
+					if !services.HasPlainHistory(tx, item.Key) {
+						continue
+					}
+
+					issue := secretIssue{Key: item.Key, Issue: secretIssuePlainHistory}
+
+					// Keys in vaults are not encrypted with a password
+					if fix == "lock" && services.FindVault(tx, item.Key) == nil {
+						services.ScrubKeyHistory(tx, item.Key, encrypt)
+						issue.Fixed, scrubbed = true, true
+					}
+
+					issues = append(issues, issue)
+					continue
+				}
+
+				if item.IsHidden {
+					continue
+				}
+
+				kind, found := common.DetectSecret(item.Value)
+				if !found {
+					continue
+				}
+
+				issue := secretIssue{Key: item.Key, Issue: secretIssueExposed, Kind: kind}
+
+				switch fix {
+				case "hide":
+					services.HideKey(tx, item.Key)
+					issue.Fixed = true
+				case "lock":
+					services.LockKey(tx, item.Key, encrypt)
+					services.ScrubKeyHistory(tx, item.Key, encrypt)
+					issue.Fixed, scrubbed = true, true
+				}
+
+				issues = append(issues, issue)
+			}
+		})
+
+		// Make sure encrypted plain text is gone from disk
+		if scrubbed {
+			if err := common.CompactDB(); err != nil {
+				common.Fail("Could not compact database: %v", err)
+			}
+		}
+
+		if len(issues) == 0 {
+			common.Stderr.Println("No issues found.")
+			return
+		}
+
+		printSecretIssues(issues)
+
+		for _, issue := range issues {
+			if !issue.Fixed {
+				common.Fail("")
+			}
+		}
+	},
+}
+
+func printSecretIssues(issues []secretIssue) {
+	switch doctorSecretsFlags.output {
+	case "yaml":
+		output, _ := yaml.Marshal(issues)
+		common.Stdout.Println(string(output))
+	case "json":
+		output, _ := json.MarshalIndent(issues, "", "  ")
+		common.Stdout.Println(string(output))
+	case "table":
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader([]any{"Key", "Issue", "Kind", "Status"})
+
+		for _, issue := range issues {
+			kind := issue.Kind
+			if kind == "" {
+				kind = "-"
+			}
+
+			status := color.New(color.FgRed).Sprint("Open")
+			if issue.Fixed {
+				status = color.New(color.FgGreen).Sprint("Fixed")
+			}
+
+			t.AppendRow([]any{
+				color.New(color.FgBlue).Sprint(issue.Key),
+				color.New(color.FgYellow).Sprint(issue.Issue),
+				kind,
+				status,
+			})
+		}
+
+		t.SetStyle(table.StyleLight)
+		t.Render()
+	default:
+		common.Fail("Unsupported format %q", doctorSecretsFlags.output)
+	}
+}
+
+func init() {
+	doctorCmd.AddCommand(doctorSecretsCmd)
+
+	doctorSecretsCmd.Flags().StringVar(&doctorSecretsFlags.fix, "fix", "", "Fix exposed secrets, options: hide, lock")
+	_ = doctorSecretsCmd.RegisterFlagCompletionFunc(
+		"fix",
+		cobra.FixedCompletions([]string{"hide", "lock"}, cobra.ShellCompDirectiveDefault),
+	)
+
+	doctorSecretsCmd.Flags().StringVarP(&doctorSecretsFlags.output, "output", "o", "table", "Print format, options: table, json, yaml")
+	_ = doctorSecretsCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveDefault),
+	)
+
+	addPasswordFlags(doctorSecretsCmd, "Password to lock exposed secrets with, for --fix lock")
+}
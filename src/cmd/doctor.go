@@ -0,0 +1,14 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the store for problems",
+	Long:  `Check the store for problems and optionally fix them.`,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -12,11 +12,12 @@ import (
 var expireFlags = struct {
 	after time.Duration
 	never bool
+	tags  []string
 }{}
 
 // expireCmd represents the expire command
 var expireCmd = &cobra.Command{
-	Use:     "expire <key|key1 key2...>",
+	Use:     "expire <key|key1 key2...|--tag tag>",
 	Aliases: []string{"ex", "exp"},
 	Short:   "Set or remove expiration for a key or keys",
 	Long: `Set or remove expiration for a key or multiple keys.
@@ -25,7 +26,8 @@ Use --after with duration suffixes: s (second), m (minute), h (hour)
 Example durations: 1h, 30m, 10s, 2h3m4s
 
 Use --never to remove expiration.
-Providing a negative duration expires the key immediately.`,
+Providing a negative duration expires the key immediately.
+With --tag, the expiration of all keys with given tags is changed, see 'kv annotate'.`,
 	Example: `  # Set key to expire in 1 hour
   kv expire session-token --after 1h
 
@@ -36,15 +38,38 @@ Providing a negative duration expires the key immediately.`,
   kv expire session-token api-key --never
 
   # Expire key immediately
-  kv expire old-token --after -1s`,
+  kv expire old-token --after -1s
+
+  # Expire all keys tagged session in 10 minutes
+  kv expire --tag session --after 10m`,
 	GroupID: "ttl",
-	Args:    cobra.MinimumNArgs(1),
+	Args:    cobra.ArbitraryArgs,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(expireFlags.tags) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
 		return completeKeyArg(toComplete, services.MatchExisting)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		tagged := len(expireFlags.tags) > 0
+		if tagged && len(args) > 0 {
+			common.Fail("Cannot have arguments with --tag")
+		}
+
+		if !tagged && len(args) == 0 {
+			common.Fail("At least one key must be provided")
+		}
+
+		validateTags(expireFlags.tags)
+
 		services.RunInTransaction(func(tx *sql.Tx) {
-			for _, key := range args {
+			keys := args
+			if tagged {
+				keys = services.ListTaggedKeys(tx, expireFlags.tags)
+			}
+
+			for _, key := range keys {
 				item := services.GetItem(tx, key)
 				if item == nil {
 					common.Fail("Key %q does not exist", key)
@@ -67,6 +92,8 @@ func init() {
 	expireCmd.Flags().DurationVar(&expireFlags.after, "after", 0, "Expires this value after given duration.")
 	expireCmd.Flags().BoolVar(&expireFlags.never, "never", false, "Remove any expiration from the key.")
 
+	addTagFlag(expireCmd, &expireFlags.tags, "tag", "Change the expiration of all keys with given tag, can be repeated")
+
 	expireCmd.MarkFlagsMutuallyExclusive("never", "after")
 	expireCmd.MarkFlagsOneRequired("never", "after")
 }
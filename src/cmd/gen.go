@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var genFlags = struct {
+	lock   bool
+	hidden bool
+}{}
+
+// defaultSecretLength is the generated value length for character sets, and defaultWordCount the number of words for "words"
+const (
+	defaultSecretLength = 32
+	defaultWordCount    = 6
+)
+
+// genCmd represents the gen command
+var genCmd = &cobra.Command{
+	Use:   "gen <key>",
+	Short: "Generate a random value for a new key",
+	Long: `Generate a cryptographically secure random value and store it in a new key.
+
+The value is generated from the character set given with --charset:
+  alnum   letters and digits (default)
+  hex     lowercase hexadecimal digits
+  base64  URL-safe base64 characters
+  words   dash-separated words from the BIP39 list, --length is then the number of words (default 6)
+
+The value is not printed, read it with 'kv get' or copy it to the clipboard with --clip.
+Use 'kv rotate' to replace the value of an existing key with a newly generated one.`,
+	Example: `  # Generate a 32 character token
+  kv gen api-token
+
+  # Generate a long hex secret and lock it with a password
+  kv gen signing-key --length 64 --charset hex --lock
+
+  # Generate a passphrase and copy it to the clipboard
+  kv gen wifi-password --charset words --clip`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+		value := generateValue(cmd)
+
+		// Checked again when writing, this fails early before asking for a password
+		var vault *services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			ensureGenKeyAbsent(tx, key)
+			vault = services.FindVault(tx, key)
+		})
+
+		storedValue, isLocked := value, false
+
+		if vault != nil {
+			var err error
+			storedValue, err = common.EncryptWithKey(value, readVaultKey(cmd, *vault))
+			common.FailOn(err)
+
+			isLocked = true
+		} else if genFlags.lock || passwordRequested(cmd) {
+			password := readPassword(cmd, true)
+			if password == "" {
+				common.Fail("Password cannot be empty")
+			}
+
+			var err error
+			storedValue, err = common.Encrypt(value, password)
+			common.FailOn(err)
+
+			isLocked = true
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			ensureGenKeyAbsent(tx, key)
+
+			// Validated before encryption, as locked values cannot be validated later
+			services.EnsureSchemas(tx, key, "", value)
+
+			services.SetValue(tx, key, storedValue, nil, isLocked)
+			services.MarkRotated(tx, key)
+
+			if genFlags.hidden {
+				services.HideKey(tx, key)
+			}
+		})
+
+		common.Stderr.Printf("Generated value for %q\n", key)
+
+		if clipRequested(cmd) {
+			copyToClipboard(cmd, value)
+		}
+	},
+}
+
+func ensureGenKeyAbsent(tx *sql.Tx, key string) {
+	if services.GetItem(tx, key) != nil {
+		common.Fail("Key %q already exists, use 'kv rotate' to generate a new value", key)
+	}
+}
+
+// addGenerateFlags defines --length and --charset flags on cmd
+func addGenerateFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("length", "l", defaultSecretLength, "Length of the generated value, or number of words for 'words' charset")
+	cmd.Flags().StringP("charset", "c", "alnum", "Character set of the generated value, options: alnum, hex, base64, words")
+	_ = cmd.RegisterFlagCompletionFunc(
+		"charset",
+		cobra.FixedCompletions(common.Charsets, cobra.ShellCompDirectiveDefault),
+	)
+}
+
+// generateValue returns a random value according to cmd's --length and --charset flags
+func generateValue(cmd *cobra.Command) string {
+	charset, _ := cmd.Flags().GetString("charset")
+	length, _ := cmd.Flags().GetInt("length")
+
+	if charset == "words" && !cmd.Flags().Changed("length") {
+		length = defaultWordCount
+	}
+
+	value, err := common.GenerateSecret(charset, length)
+	if err != nil {
+		common.Fail("Could not generate value: %v", err)
+	}
+
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(genCmd)
+
+	addGenerateFlags(genCmd)
+	addPasswordFlags(genCmd, "Password to lock the generated value")
+	addClipFlags(genCmd)
+
+	genCmd.Flags().BoolVar(&genFlags.lock, "lock", false, "Lock the generated value with a password")
+	genCmd.Flags().BoolVar(&genFlags.hidden, "hidden", false, "Mark key as hidden")
+}
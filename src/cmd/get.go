@@ -2,47 +2,103 @@ package cmd
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/AmrSaber/kv/src/common"
 	"github.com/AmrSaber/kv/src/services"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
+var getFlags = struct {
+	render    bool
+	noDeref   bool
+	valueType string
+	path      string
+	meta      bool
+
+	output string
+	strict bool
+}{}
+
 // getCmd represents the get command
 var getCmd = &cobra.Command{
-	Use:   "get <key>",
-	Short: "Retrieve the value for the specified key",
-	Long: `Retrieve the value for the specified key.
+	Use:   "get <key>...",
+	Short: "Retrieve the value for the specified keys",
+	Long: `Retrieve the value of a key, or the values of several keys at once.
+
+Locked keys are decrypted with the password flags, KV_PASSWORD or the password-command config,
+keys in an unlocked vault or locked with --recipient need no password, see 'kv vault' and 'kv keygen'.
+Links are followed to the key they point to, see 'kv link'.
 
-If the key is encrypted, provide the password using --password flag.`,
+With multiple keys or --output, all values are read in a single transaction and printed as a map,
+--render, --path, --meta and --clip only apply to a single key.`,
 	Example: `  # Get a plain value
   kv get api-key
 
-  # Get an encrypted value
-  kv get github-token --password=mypass
-
   # Get an encrypted value, enter password interactively
   kv get github-token --password
 
+  # Copy a secret to the clipboard without printing it, clearing it after 30 seconds
+  kv get github-token --clip
+
+  # Get a value composed from other keys
+  kv get db.url --render
+
+  # Get a single field of a JSON value, failing if it's not an int
+  kv get app.config --path .server.port --type int
+
+  # Get the metadata and version of a key
+  kv get api-key --meta
+
+  # Load multiple values as environment variables, failing if any is missing
+  eval "$(kv get db.host db.port -o env --strict)"
+
   # Use in a shell script
   curl -H "Authorization: Bearer $(kv get api-key)" https://api.example.com`,
 	GroupID: "kv",
-	Args:    cobra.ExactArgs(1),
+	Args:    cobra.MinimumNArgs(1),
 
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
-		if len(args) != 0 {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
-
 		return completeKeyArg(toComplete, services.MatchExisting)
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := common.ValidateType(getFlags.valueType); err != nil {
+			common.Fail("Invalid type: %v", err)
+		}
+
+		if len(args) > 1 || cmd.Flags().Changed("output") {
+			for _, flag := range []string{"meta", "path", "render", "clip"} {
+				if cmd.Flags().Changed(flag) {
+					common.Fail("--%s can only be used with a single key and without --output", flag)
+				}
+			}
+
+			getValues(cmd, args)
+			return
+		}
+
 		key := args[0]
 		var item *services.KVItem
+		var vault *services.Vault
+		var stats *services.KeyStats
 
 		services.RunInTransaction(func(tx *sql.Tx) {
+			if !getFlags.noDeref {
+				key = resolveLinkedKey(tx, key)
+			}
+
 			item = services.GetItem(tx, key)
+			vault = services.FindVault(tx, key)
+
+			if getFlags.meta {
+				stats = services.GetKeyStats(tx, key)
+			}
 		})
 
 		if item == nil {
@@ -50,32 +106,263 @@ If the key is encrypted, provide the password using --password flag.`,
 			return // To shut up the compiler
 		}
 
-		if item.IsLocked && !cmd.Flags().Changed("password") {
-			common.Fail("Key is locked, please pass the password with --password flag")
+		if getFlags.meta {
+			output, _ := yaml.Marshal(stats)
+			common.Stdout.Print(string(output))
+			return
 		}
 
-		var password string
-		passwordFlag := cmd.Flags().Lookup("password")
-		if passwordFlag.Changed {
-			password = readPassword(cmd, false)
-		}
+		var value string
+		if getFlags.render {
+			renderer := newTemplateRenderer(cmd)
 
-		value := item.Value
-		if password != "" {
 			var err error
-			value, err = common.Decrypt(value, password)
+			value, err = renderer.render(key, renderer.decrypt(key, *item, vault), []string{key})
 			if err != nil {
-				common.Fail("Wrong password")
+				common.Fail("Could not render key %q: %v", key, err)
 			}
+		} else {
+			value = readValue(cmd, key, *item, vault)
 		}
 
-		common.Stdout.Println(value)
+		if cmd.Flags().Changed("path") {
+			value = readPath(key, value, getFlags.path)
+		}
+
+		if getFlags.valueType != "" {
+			if err := common.ValidateValue(getFlags.valueType, value); err != nil {
+				common.Fail("Value of key %q is not a valid %s: %v", key, getFlags.valueType, err)
+			}
+		}
+
+		printValue(cmd, value)
 	},
 }
 
+// getValues prints the values of keys as a map in the format given by --output, reading all keys in a single transaction
+func getValues(cmd *cobra.Command, keys []string) {
+	type foundItem struct {
+		key      string
+		resolved string
+		item     services.KVItem
+		vault    *services.Vault
+	}
+
+	var found []foundItem
+	var missing []string
+
+	services.RunInTransaction(func(tx *sql.Tx) {
+		for _, key := range keys {
+			if slices.ContainsFunc(found, func(f foundItem) bool { return f.key == key }) || slices.Contains(missing, key) {
+				continue
+			}
+
+			resolved := key
+			if !getFlags.noDeref {
+				var err error
+				if resolved, err = services.ResolveLink(tx, key); err != nil {
+					missing = append(missing, key)
+					continue
+				}
+			}
+
+			item := services.GetItem(tx, resolved)
+			if item == nil {
+				missing = append(missing, key)
+				continue
+			}
+
+			found = append(found, foundItem{key: key, resolved: resolved, item: *item, vault: services.FindVault(tx, resolved)})
+		}
+	})
+
+	if getFlags.strict && len(missing) > 0 {
+		common.Fail("Missing keys: %s", strings.Join(missing, ", "))
+	}
+
+	values := map[string]string{}
+	typedValues := map[string]any{}
+	reader := newValueReader(cmd)
+	for _, f := range found {
+		// The password given for locked values does not apply to plain ones
+		value := f.item.Value
+		if f.item.IsLocked {
+			value = reader.read(f.resolved, f.item, f.vault)
+		}
+
+		if getFlags.valueType != "" {
+			if err := common.ValidateValue(getFlags.valueType, value); err != nil {
+				common.Fail("Value of key %q is not a valid %s: %v", f.key, getFlags.valueType, err)
+			}
+		}
+
+		values[f.key] = value
+		typedValues[f.key] = common.TypedValue(f.item.Type, value)
+	}
+
+	switch getFlags.output {
+	case "", "json":
+		output, _ := json.MarshalIndent(typedValues, "", "  ")
+		common.Stdout.Println(string(output))
+	case "yaml":
+		output, _ := yaml.Marshal(typedValues)
+		common.Stdout.Print(string(output))
+	case "env":
+		// Names are checked before printing, so output is never partially evaluated
+		names := map[string]string{}
+		for _, f := range found {
+			name := envVarName(f.key)
+			if other, taken := names[name]; taken {
+				common.Fail("Keys %q and %q have the same variable name %s", other, f.key, name)
+			}
+
+			names[name] = f.key
+		}
+
+		for _, f := range found {
+			common.Stdout.Printf("%s=%s\n", envVarName(f.key), shellQuote(values[f.key]))
+		}
+	default:
+		common.Fail("Unsupported format %q", getFlags.output)
+	}
+
+	for _, key := range missing {
+		common.Warn(fmt.Sprintf("Key %q does not exist", key))
+	}
+}
+
+var envVarInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// envVarName converts key to an environment variable name, e.g. db.host to DB_HOST
+func envVarName(key string) string {
+	name := strings.ToUpper(envVarInvalidChars.ReplaceAllString(key, "_"))
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// shellQuote quotes value in single quotes for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// readValue returns the plain value of item, decrypting it with the vault key, identity, cached key or password as needed
+func readValue(cmd *cobra.Command, key string, item services.KVItem, vault *services.Vault) string {
+	return newValueReader(cmd).read(key, item, vault)
+}
+
+// valueReader decrypts values like readValue, reading the password and the key of each vault at most once,
+// for commands that decrypt several values
+type valueReader struct {
+	cmd       *cobra.Command
+	password  *string
+	vaultKeys map[string][]byte
+}
+
+func newValueReader(cmd *cobra.Command) *valueReader {
+	return &valueReader{cmd: cmd, vaultKeys: map[string][]byte{}}
+}
+
+// readPassword returns the password of the command, reading it on first use
+func (r *valueReader) readPassword() string {
+	if r.password == nil {
+		password := readPassword(r.cmd, false)
+		r.password = &password
+	}
+
+	return *r.password
+}
+
+// vaultKey returns the key of vault, reading it on first use
+func (r *valueReader) vaultKey(vault services.Vault) []byte {
+	if key, found := r.vaultKeys[vault.Prefix]; found {
+		return key
+	}
+
+	key := readVaultKeyWith(r.cmd, vault, r.readPassword)
+	r.vaultKeys[vault.Prefix] = key
+
+	return key
+}
+
+// read returns the plain value of item, decrypting it with the vault key, identity, cached key or password as needed
+func (r *valueReader) read(key string, item services.KVItem, vault *services.Vault) string {
+	if item.IsLocked && vault != nil {
+		value, err := common.DecryptWithKey(item.Value, r.vaultKey(*vault))
+		if err != nil {
+			common.Fail("Could not decrypt key %q with vault key", key)
+		}
+
+		return value
+	}
+
+	if item.IsLocked && common.IsRecipientEncrypted(item.Value) {
+		return decryptWithIdentity(key, item.Value)
+	}
+
+	if item.IsLocked {
+		if value, found := decryptWithCachedKey(key, item.Value); found {
+			return value
+		}
+	}
+
+	if item.IsLocked && !passwordAvailable(r.cmd) {
+		common.Fail("Key is locked, please pass the password with --password flag")
+	}
+
+	var password string
+	if item.IsLocked || passwordRequested(r.cmd) {
+		password = r.readPassword()
+	}
+
+	value := item.Value
+	if password != "" {
+		var err error
+		value, err = common.Decrypt(value, password)
+		if err != nil {
+			common.Fail("Wrong password")
+		}
+
+		cacheDerivedKey(key, item.Value, password)
+	}
+
+	return value
+}
+
+// printValue prints value, or copies it to the clipboard if --clip was passed
+func printValue(cmd *cobra.Command, value string) {
+	if clipRequested(cmd) {
+		copyToClipboard(cmd, value)
+		return
+	}
+
+	common.Stdout.Println(value)
+}
+
 func init() {
 	rootCmd.AddCommand(getCmd)
 
-	getCmd.Flags().StringP("password", "p", "", "Password to decrypt value if it's encrypted")
-	getCmd.Flags().Lookup("password").NoOptDefVal = passwordPromptSentinel
+	addPasswordFlags(getCmd, "Password to decrypt value if it's encrypted")
+	addClipFlags(getCmd)
+	getCmd.Flags().StringVarP(&getFlags.valueType, "type", "t", "", "Fail unless the value is valid for given type whatever it was stored with, options: string, int, bool, json, url, duration")
+	_ = getCmd.RegisterFlagCompletionFunc(
+		"type",
+		cobra.FixedCompletions(common.ValueTypes, cobra.ShellCompDirectiveDefault),
+	)
+	getCmd.Flags().BoolVar(&getFlags.noDeref, "no-deref", false, "Print the target of a link instead of following it")
+	getCmd.Flags().BoolVarP(&getFlags.render, "render", "r", false, "Render the value as a template, replacing {{kv \"key\"}} with the value of key, see 'kv render'")
+	getCmd.Flags().StringVar(&getFlags.path, "path", "", "Only print the part of a JSON or YAML value at given path, e.g. .server.port or .hosts[0]")
+	getCmd.Flags().BoolVar(&getFlags.meta, "meta", false, "Print the metadata of the key as YAML instead of its value, like 'kv stat -o yaml'")
+	getCmd.MarkFlagsMutuallyExclusive("meta", "render")
+	getCmd.MarkFlagsMutuallyExclusive("meta", "path")
+	getCmd.MarkFlagsMutuallyExclusive("meta", "clip")
+
+	getCmd.Flags().StringVarP(&getFlags.output, "output", "o", "", "Print the values of the keys as a map, options: json (default), yaml, env (NAME='value' lines, NAME being the upper-cased key with other characters than letters and digits replaced by _)")
+	_ = getCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "env"}, cobra.ShellCompDirectiveDefault),
+	)
+	getCmd.Flags().BoolVar(&getFlags.strict, "strict", false, "Fail if any of the keys does not exist, instead of reporting it on stderr")
 }
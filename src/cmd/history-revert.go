@@ -51,15 +51,29 @@ Calling revert multiple times without other changes will toggle between the curr
 
 		services.RunInTransaction(func(tx *sql.Tx) {
 			item = services.GetHistoryItem(tx, key, historyRevertFlags.steps)
-			services.SetValue(tx, key, item.Value, nil, item.IsLocked)
+			restoreHistoryItem(tx, key, item)
 		})
 
-		if !item.IsLocked {
+		switch {
+		case item.IsLink:
+			common.Stderr.Printf("%s -> %s\n", key, item.Value)
+		case !item.IsLocked:
 			common.Stdout.Println(item.Value)
 		}
 	},
 }
 
+// restoreHistoryItem makes a record from the history of key its latest value, links are restored as links
+func restoreHistoryItem(tx *sql.Tx, key string, item services.KVItem) {
+	if item.IsLink {
+		services.SetLink(tx, key, item.Value)
+		return
+	}
+
+	services.SetValue(tx, key, item.Value, nil, item.IsLocked)
+	services.SetValueType(tx, key, item.Type)
+}
+
 func init() {
 	historyCmd.AddCommand(historyRevertCmd)
 
@@ -26,7 +26,10 @@ This provides a user-friendly way to browse and choose from previous values.`,
   kv history select api-key
 
   # Select from history without showing values
-  kv history select api-key --no-values`,
+  kv history select api-key --no-values
+
+  # Copy the selected value to the clipboard instead of printing it
+  kv history select api-key --no-values --clip`,
 	Args: cobra.ExactArgs(1),
 
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
@@ -65,6 +68,8 @@ This provides a user-friendly way to browse and choose from previous values.`,
 
 				if item.IsLocked {
 					value = color.New(color.FgRed).Sprint("[Locked]")
+				} else if item.IsLink {
+					value = "-> " + color.New(color.FgCyan).Sprint(item.Value)
 				}
 
 				row := fmt.Sprintf(
@@ -91,13 +96,23 @@ This provides a user-friendly way to browse and choose from previous values.`,
 			}
 
 			selectedItem = items[selectedIndex]
-
-			services.SetValue(tx, key, selectedItem.Value, nil, selectedItem.IsLocked)
+			restoreHistoryItem(tx, key, selectedItem)
 		})
 
-		if !selectedItem.IsLocked {
-			common.Stdout.Println(selectedItem.Value)
+		if selectedItem.IsLink {
+			common.Stderr.Printf("%s -> %s\n", key, selectedItem.Value)
+			return
 		}
+
+		if selectedItem.IsLocked {
+			if clipRequested(cmd) {
+				common.Stderr.Println("Selected value is locked, it was not copied to the clipboard")
+			}
+
+			return
+		}
+
+		printValue(cmd, selectedItem.Value)
 	},
 }
 
@@ -105,4 +120,5 @@ func init() {
 	historyCmd.AddCommand(historySelectCmd)
 
 	historySelectCmd.Flags().BoolVarP(&historySelectFlags.noValues, "no-values", "v", false, "Hide values")
+	addClipFlags(historySelectCmd)
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var incrFlags = struct {
+	by int64
+}{}
+
+// incrCmd represents the incr command
+var incrCmd = &cobra.Command{
+	Use:   "incr <key>",
+	Short: "Increment the integer value of a key",
+	Long: `Increment the integer value of a key by 1, or by --by, and print the new value.
+
+The value is read and written in a single transaction, so concurrent increments are never lost.
+Keys that do not exist start from 0. The expiry of the key is kept, and the change is recorded in history like any other value.
+Locked keys and keys inside a vault cannot be incremented.`,
+	Example: `  # Increment a build number
+  kv incr ci.build-number
+
+  # Increment by a given step
+  kv incr stats.requests --by 10`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		incrementKey(args[0], incrFlags.by)
+	},
+}
+
+// incrementKey adds by to the value of key atomically and prints the new value
+func incrementKey(key string, by int64) {
+	var value int64
+	services.RunInTransaction(func(tx *sql.Tx) {
+		key = resolveLinkedKey(tx, key)
+		value = services.IncrementValue(tx, key, by)
+	})
+
+	common.Stdout.Println(value)
+}
+
+func init() {
+	rootCmd.AddCommand(incrCmd)
+
+	incrCmd.Flags().Int64Var(&incrFlags.by, "by", 1, "Amount to add")
+}
@@ -23,6 +23,7 @@ It just displays the path where a backup would be if there were one.`,
 		type Info struct {
 			DataDir    string `json:"dataDir" yaml:"data-dir"`
 			BackupPath string `json:"backupPath" yaml:"backup-path"`
+			Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
 
 			Config common.Config `json:"config" yaml:"config"`
 		}
@@ -30,6 +31,7 @@ It just displays the path where a backup would be if there were one.`,
 		info := Info{
 			DataDir:    filepath.Dir(common.GetDBPath()),
 			BackupPath: common.GetDefaultBackupPath(),
+			Encrypted:  common.IsDBEncrypted(),
 			Config:     common.ReadConfig(),
 		}
 
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/spf13/cobra"
+)
+
+var keygenFlags = struct{ public bool }{}
+
+// keygenCmd represents the keygen command
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create an identity for public key encryption",
+	Long: `Create an age X25519 identity and print its public key.
+
+The identity is stored outside the database, in 'identity.txt' next to the config file,
+or at the path given by the 'identity' config. It is used automatically to decrypt values
+locked with 'kv lock --recipient', and is compatible with the age tool.
+
+Share the public key with others so they can encrypt values for you.`,
+	Example: `  # Create an identity
+  kv keygen
+
+  # Print the public key of the existing identity
+  kv keygen --public
+
+  # Lock a value for yourself
+  kv lock api-key --recipient "$(kv keygen --public)"`,
+	GroupID: "security",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		identityPath := common.GetIdentityPath()
+
+		if keygenFlags.public {
+			identities, err := common.ReadIdentities()
+			if errors.Is(err, common.ErrNoIdentity) {
+				common.Fail("No identity found at %q, create one with 'kv keygen'", identityPath)
+			}
+			if err != nil {
+				common.Fail("Could not read identity %q: %v", identityPath, err)
+			}
+
+			for _, identity := range identities {
+				common.Stdout.Println(identityRecipient(identity))
+			}
+
+			return
+		}
+
+		if _, err := os.Stat(identityPath); err == nil {
+			common.Fail("Identity already exists at %q, print its public key with 'kv keygen --public'", identityPath)
+		}
+
+		identity, err := common.GenerateIdentity(identityPath)
+		if err != nil {
+			common.Fail("Could not create identity: %v", err)
+		}
+
+		common.Stderr.Printf("Identity created at %q\n", identityPath)
+		common.Stdout.Println(identity.Recipient())
+	},
+}
+
+// identityRecipient returns the public key of identity
+func identityRecipient(identity age.Identity) string {
+	switch identity := identity.(type) {
+	case *age.X25519Identity:
+		return identity.Recipient().String()
+	case *age.HybridIdentity:
+		return identity.Recipient().String()
+	default:
+		return fmt.Sprintf("%T", identity)
+	}
+}
+
+// decryptWithIdentity decrypts a value locked with --recipient using the configured identity
+func decryptWithIdentity(key string, value string) string {
+	identities, err := common.ReadIdentities()
+	if errors.Is(err, common.ErrNoIdentity) {
+		common.Fail("Key %q is encrypted to public keys, but no identity was found at %q", key, common.GetIdentityPath())
+	}
+	if err != nil {
+		common.Fail("Could not read identity %q: %v", common.GetIdentityPath(), err)
+	}
+
+	plaintext, err := common.DecryptWithIdentities(value, identities)
+	if err != nil {
+		common.Fail("Could not decrypt key %q with identity %q", key, common.GetIdentityPath())
+	}
+
+	return plaintext
+}
+
+func init() {
+	rootCmd.AddCommand(keygenCmd)
+
+	keygenCmd.Flags().BoolVar(&keygenFlags.public, "public", false, "Print the public key of the existing identity instead of creating one")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// linkCmd represents the link command
+var linkCmd = &cobra.Command{
+	Use:   "link <alias> <target>",
+	Short: "Create a key that points to another key",
+	Long: `Create a link, a key that resolves to another key when read with 'get', 'otp', 'rotate' or in templates.
+
+Linking an existing link again points it to the new target, keeping the old target in history.
+Existing keys that are not links are never replaced, but 'kv set' on a link replaces the link with a value.
+
+Links can point to other links, but not in a cycle. If the target of a link is deleted, reading the link fails
+until it's pointed to an existing key again. Use 'kv get --no-deref' to read the target of a link,
+and 'kv delete' to remove the link itself.`,
+	Example: `  # Point db.current to the staging database
+  kv link db.current db.staging
+  kv get db.current
+
+  # Switch to production with one command
+  kv link db.current db.prod
+
+  # Print where a link points to
+  kv get db.current --no-deref`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(2),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) >= 2 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		alias, target := args[0], args[1]
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			if current := services.GetItem(tx, alias); current != nil && !current.IsLink {
+				common.Fail("Key %q already exists and is not a link", alias)
+			}
+
+			services.SetLink(tx, alias, target)
+		})
+
+		common.Stderr.Printf("%s -> %s\n", alias, target)
+	},
+}
+
+// resolveLinkedKey returns the key holding the value of key, following links
+func resolveLinkedKey(tx *sql.Tx, key string) string {
+	resolved, err := services.ResolveLink(tx, key)
+	if err != nil {
+		common.Fail("Could not resolve key %q: %v", key, err)
+	}
+
+	return resolved
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+}
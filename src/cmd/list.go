@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -20,6 +21,8 @@ var listFlags = struct {
 	deleted  bool
 	noValues bool
 	show     bool
+	stale    string
+	tags     []string
 
 	output string
 }{}
@@ -32,7 +35,14 @@ var listCmd = &cobra.Command{
 	Long: `List all keys in the store, optionally filtered by prefix.
 
 Output formats available: table (default), json, yaml
-Locked values are displayed as [Locked] in table view.`,
+Locked values are displayed as [Locked] in table view.
+Values of typed keys are output as numbers, booleans or objects in json and yaml formats, see 'kv set --type'.
+Links are displayed as "alias -> target", in red if they cannot be resolved, see 'kv link'.
+Descriptions and tags of keys are displayed as well, and --tag only lists keys with all given tags, see 'kv annotate'.
+
+With --stale, only keys that were not rotated within the given duration are listed, see 'kv rotate'.
+Keys that were never rotated are compared by the time of their last change.
+Durations accept d (day) and w (week) units in addition to h, m and s, e.g. 90d or 2w.`,
 	Example: `  # List all keys
   kv list
 
@@ -46,7 +56,13 @@ Locked values are displayed as [Locked] in table view.`,
   kv list --no-values
 
   # List deleted keys
-  kv list --deleted`,
+  kv list --deleted
+
+  # List keys tagged both prod and db
+  kv list --tag prod --tag db
+
+  # List secrets not rotated in the last 90 days
+  kv list secrets --stale 90d`,
 	GroupID: "kv",
 	Args:    cobra.MaximumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
@@ -66,6 +82,18 @@ Locked values are displayed as [Locked] in table view.`,
 			prefix = args[0]
 		}
 
+		validateTags(listFlags.tags)
+
+		var staleBefore time.Time
+		if cmd.Flags().Changed("stale") {
+			staleAfter, err := common.ParseDuration(listFlags.stale)
+			if err != nil || staleAfter < 0 {
+				common.Fail("Invalid stale duration %q", listFlags.stale)
+			}
+
+			staleBefore = time.Now().Add(-staleAfter)
+		}
+
 		matchType := services.MatchExisting
 		if listFlags.deleted {
 			matchType = services.MatchDeleted
@@ -73,13 +101,46 @@ Locked values are displayed as [Locked] in table view.`,
 		}
 
 		var items []services.KVItem
+		linkTargets, danglingLinks := map[string]string{}, map[string]bool{}
 
 		services.RunInTransaction(func(tx *sql.Tx) {
 			items = services.ListItems(tx, prefix, matchType)
+
+			for _, item := range items {
+				if !item.IsLink {
+					continue
+				}
+
+				linkTargets[item.Key] = item.Value
+				if _, err := services.ResolveLink(tx, item.Key); err != nil {
+					danglingLinks[item.Key] = true
+				}
+			}
 		})
 
+		if len(listFlags.tags) > 0 {
+			items = slices.DeleteFunc(items, func(item services.KVItem) bool {
+				return slices.ContainsFunc(listFlags.tags, func(tag string) bool { return !slices.Contains(item.Tags, tag) })
+			})
+		}
+
+		if !staleBefore.IsZero() {
+			items = slices.DeleteFunc(items, func(item services.KVItem) bool {
+				lastRotated := item.Timestamp
+				if item.RotatedAt != nil {
+					lastRotated = *item.RotatedAt
+				}
+
+				return !lastRotated.Before(staleBefore)
+			})
+		}
+
 		if len(items) == 0 {
-			if listFlags.deleted {
+			if len(listFlags.tags) > 0 {
+				common.Stderr.Println("No tagged items.")
+			} else if !staleBefore.IsZero() {
+				common.Stderr.Println("No stale items.")
+			} else if listFlags.deleted {
 				common.Stderr.Println("No deleted items.")
 			} else {
 				common.Stderr.Println("No saved items. Use `kv set` to add one.")
@@ -107,10 +168,15 @@ Locked values are displayed as [Locked] in table view.`,
 			}
 		}
 
-		hasExpires, hasLocked := false, false
+		hasExpires, hasLocked, hasRotated, hasTypes := false, false, false, false
+		hasDescriptions, hasTags := false, false
 		for _, item := range items {
+			hasTypes = hasTypes || (item.Type != "")
+			hasDescriptions = hasDescriptions || (item.Description != "")
+			hasTags = hasTags || (len(item.Tags) > 0)
 			hasExpires = hasExpires || (item.ExpiresAt != nil)
 			hasLocked = hasLocked || item.IsLocked
+			hasRotated = hasRotated || (item.RotatedAt != nil)
 		}
 
 		switch listFlags.output {
@@ -133,12 +199,28 @@ Locked values are displayed as [Locked] in table view.`,
 				header = append(header, "Value")
 			}
 
+			if hasTypes {
+				header = append(header, "Type")
+			}
+
+			if hasTags {
+				header = append(header, "Tags")
+			}
+
+			if hasDescriptions {
+				header = append(header, "Description")
+			}
+
 			header = append(header, "Timestamp")
 
 			if hasExpires {
 				header = append(header, "Expires At")
 			}
 
+			if hasRotated {
+				header = append(header, "Rotated At")
+			}
+
 			if displayLocked {
 				header = append(header, "Locked")
 			}
@@ -151,10 +233,23 @@ Locked values are displayed as [Locked] in table view.`,
 					expiresAt = item.ExpiresAt.Local().Format(time.DateTime)
 				}
 
-				row := []any{color.New(color.FgBlue).Sprint(item.Key)}
+				key := color.New(color.FgBlue).Sprint(item.Key)
+				if item.IsLink {
+					targetColor := color.New(color.FgCyan)
+					if danglingLinks[item.Key] {
+						targetColor = color.New(color.FgRed)
+					}
+
+					key += " -> " + targetColor.Sprint(linkTargets[item.Key])
+				}
+
+				row := []any{key}
 
 				if displayValues {
 					value := item.Value
+					if item.IsLink {
+						value = ""
+					}
 
 					// [Locked] takes precedence over [Hidden]
 					if item.IsLocked {
@@ -166,12 +261,48 @@ Locked values are displayed as [Locked] in table view.`,
 					row = append(row, value)
 				}
 
+				if hasTypes {
+					valueType := "-"
+					if item.Type != "" {
+						valueType = item.Type
+					}
+
+					row = append(row, color.New(color.FgMagenta).Sprint(valueType))
+				}
+
+				if hasTags {
+					tags := "-"
+					if len(item.Tags) > 0 {
+						tags = strings.Join(item.Tags, ", ")
+					}
+
+					row = append(row, color.New(color.FgCyan).Sprint(tags))
+				}
+
+				if hasDescriptions {
+					description := "-"
+					if item.Description != "" {
+						description = item.Description
+					}
+
+					row = append(row, description)
+				}
+
 				row = append(row, color.New(color.FgGreen).Sprint(item.Timestamp.Local().Format(time.DateTime)))
 
 				if hasExpires {
 					row = append(row, color.New(color.FgGreen).Sprint(expiresAt))
 				}
 
+				if hasRotated {
+					rotatedAt := "-"
+					if item.RotatedAt != nil {
+						rotatedAt = item.RotatedAt.Local().Format(time.DateTime)
+					}
+
+					row = append(row, color.New(color.FgGreen).Sprint(rotatedAt))
+				}
+
 				if displayLocked {
 					isLocked := "-"
 					if item.IsLocked {
@@ -198,6 +329,8 @@ func init() {
 	listCmd.Flags().BoolVarP(&listFlags.noValues, "no-values", "v", false, "Hide values")
 	listCmd.Flags().BoolVarP(&listFlags.deleted, "deleted", "d", false, "List deleted keys")
 	listCmd.Flags().BoolVarP(&listFlags.show, "show", "s", false, "Force-show all values")
+	listCmd.Flags().StringVar(&listFlags.stale, "stale", "", "Only list keys not rotated within given duration, e.g. 90d")
+	addTagFlag(listCmd, &listFlags.tags, "tag", "Only list keys with given tag, can be repeated")
 
 	listCmd.Flags().StringVarP(&listFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
 	_ = listCmd.RegisterFlagCompletionFunc(
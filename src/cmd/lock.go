@@ -3,14 +3,18 @@ package cmd
 import (
 	"database/sql"
 
+	"filippo.io/age"
 	"github.com/AmrSaber/kv/src/common"
 	"github.com/AmrSaber/kv/src/services"
 	"github.com/spf13/cobra"
 )
 
 var lockFlags = struct {
-	prefix bool
-	all    bool
+	prefix       bool
+	all          bool
+	recipients   []string
+	scrubHistory bool
+	tags         []string
 }{}
 
 // lockCmd represents the lock command
@@ -20,8 +24,14 @@ var lockCmd = &cobra.Command{
 	Short:   "Encrypt a key or keys with password protection",
 	Long: `Encrypt a key or multiple keys using AES-256-GCM encryption with the provided password.
 
+With --recipient, values are encrypted to one or more age public keys instead of a password,
+so each recipient can decrypt them with their own identity, see 'kv keygen'.
+Recipients can be given as public keys (age1...) or as files listing one public key per line.
+With --tag, all keys with given tags are locked, see 'kv annotate'.
+
 Note: This removes the latest record from history and replaces it with an encrypted one.
-If plain-text values exist in older history records, consider using 'kv history prune' to remove them.`,
+Older plain-text values remain in history, unless --scrub-history is given to encrypt them as well
+and compact the database so they are no longer on disk. See also 'kv db scrub'.`,
 	Example: `  # Lock a single key
   kv lock api-key --password=mypass
 
@@ -35,12 +45,21 @@ If plain-text values exist in older history records, consider using 'kv history
   kv lock secrets --prefix --password=mypass
 
   # Lock all keys in the store
-  kv lock --all --password=mypass`,
+  kv lock --all --password=mypass
+
+  # Lock all keys tagged prod
+  kv lock --tag prod --password=mypass
+
+  # Lock a key along with its older values in history
+  kv lock api-key --scrub-history --password
+
+  # Encrypt to your own identity and a teammate's public key
+  kv lock api-key --recipient "$(kv keygen --public)" --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
 	GroupID: "security",
 	Args:    cobra.ArbitraryArgs,
 
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
-		if lockFlags.all || lockFlags.prefix {
+		if lockFlags.all || lockFlags.prefix || len(lockFlags.tags) > 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
 
@@ -48,26 +67,17 @@ If plain-text values exist in older history records, consider using 'kv history
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
-		password := readPassword(cmd, true)
-		if password == "" {
-			common.Fail("Password cannot be empty")
+		if lockFlags.all && len(args) > 0 {
+			common.Fail("Cannot have arguments with --all")
 		}
 
-		if lockFlags.all {
-			if len(args) > 0 {
-				common.Fail("Cannot have arguments with --all")
-			}
-
-			services.RunInTransaction(func(tx *sql.Tx) {
-				items := services.ListItems(tx, "", services.MatchExisting)
-				for _, item := range items {
-					services.LockKey(tx, item.Key, password)
-				}
-			})
-
-			return
+		tagged := len(lockFlags.tags) > 0
+		if tagged && len(args) > 0 {
+			common.Fail("Cannot have arguments with --tag")
 		}
 
+		validateTags(lockFlags.tags)
+
 		if lockFlags.prefix {
 			if len(args) == 0 {
 				common.Fail("Prefix must be provided")
@@ -75,38 +85,90 @@ If plain-text values exist in older history records, consider using 'kv history
 			if len(args) > 1 {
 				common.Fail("Cannot use --prefix with multiple keys")
 			}
-
-			key := args[0]
-			services.RunInTransaction(func(tx *sql.Tx) {
-				items := services.ListItems(tx, key, services.MatchExisting)
-				for _, item := range items {
-					services.LockKey(tx, item.Key, password)
-				}
-			})
-
-			return
 		}
 
 		// Handle multiple keys - fail on first error
-		if len(args) == 0 {
+		if !lockFlags.all && !lockFlags.prefix && !tagged && len(args) == 0 {
 			common.Fail("At least one key must be provided")
 		}
 
+		encrypt := lockEncryption(cmd)
+		scrubbed := 0
+
 		services.RunInTransaction(func(tx *sql.Tx) {
-			for _, key := range args {
-				services.LockKey(tx, key, password)
+			keys := selectKeys(tx, args, lockFlags.all, lockFlags.prefix)
+			if tagged {
+				keys = services.ListTaggedKeys(tx, lockFlags.tags)
+			}
+
+			for _, key := range keys {
+				// Matched links are skipped, their targets are locked on their own
+				if lockFlags.all || lockFlags.prefix || tagged {
+					if item := services.GetItem(tx, key); item != nil && item.IsLink {
+						continue
+					}
+				}
+
+				services.LockKey(tx, key, encrypt)
+
+				if lockFlags.scrubHistory {
+					scrubbed += services.ScrubKeyHistory(tx, key, encrypt)
+				}
 			}
 		})
+
+		if lockFlags.scrubHistory {
+			if err := common.CompactDB(); err != nil {
+				common.Fail("Could not compact database: %v", err)
+			}
+
+			common.Stdout.Printf("Scrubbed %d history record(s)\n", scrubbed)
+		}
 	},
 }
 
+// lockEncryption returns the function that encrypts values, using --recipient flags if given or the password otherwise
+func lockEncryption(cmd *cobra.Command) func(value string) (string, error) {
+	if len(lockFlags.recipients) > 0 {
+		var recipients []age.Recipient
+		for _, arg := range lockFlags.recipients {
+			parsed, err := common.ParseRecipients(arg)
+			if err != nil {
+				common.Fail("Invalid recipient: %v", err)
+			}
+
+			recipients = append(recipients, parsed...)
+		}
+
+		return func(value string) (string, error) {
+			return common.EncryptToRecipients(value, recipients)
+		}
+	}
+
+	password := readPassword(cmd, true)
+	if password == "" {
+		common.Fail("Password cannot be empty")
+	}
+
+	return func(value string) (string, error) {
+		return common.Encrypt(value, password)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(lockCmd)
 
-	lockCmd.Flags().StringP("password", "p", "", "Encryption password")
-	lockCmd.Flags().Lookup("password").NoOptDefVal = passwordPromptSentinel
+	addPasswordFlags(lockCmd, "Encryption password")
 
 	lockCmd.Flags().BoolVar(&lockFlags.all, "all", false, "Lock all keys")
 	lockCmd.Flags().BoolVar(&lockFlags.prefix, "prefix", false, "Lock all keys with given prefix")
-	lockCmd.MarkFlagsMutuallyExclusive("all", "prefix")
+	addTagFlag(lockCmd, &lockFlags.tags, "tag", "Lock all keys with given tag, can be repeated")
+	lockCmd.MarkFlagsMutuallyExclusive("all", "prefix", "tag")
+
+	lockCmd.Flags().BoolVar(&lockFlags.scrubHistory, "scrub-history", false, "Also encrypt plain-text values in older history records")
+
+	lockCmd.Flags().StringArrayVarP(&lockFlags.recipients, "recipient", "r", nil, "Encrypt to given age public key or recipients file instead of a password, can be repeated")
+	lockCmd.MarkFlagsMutuallyExclusive("recipient", "password")
+	lockCmd.MarkFlagsMutuallyExclusive("recipient", "password-file")
+	lockCmd.MarkFlagsMutuallyExclusive("recipient", "password-fd")
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var msetFlags = struct {
+	expiresAfter time.Duration
+}{}
+
+// msetCmd represents the mset command
+var msetCmd = &cobra.Command{
+	Use:   "mset <key=value>...",
+	Short: "Store values for multiple keys at once",
+	Long: `Store values for multiple keys in a single transaction: either all values are stored, or none of them is if any fails.
+
+Each argument is a key and its value separated by the first =.
+Values are validated and encrypted like with 'kv set': keys inside a vault are encrypted with the vault key,
+and other keys are locked if a password is given.`,
+	Example: `  # Store multiple values at once
+  kv mset db.host=localhost db.port=5432 db.name=app
+
+  # Store multiple values that expire after an hour
+  kv mset session.id=abc123 session.user=alice --expires-after 1h`,
+	GroupID: "kv",
+	Args:    cobra.MinimumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var operations []common.BatchOperation
+		for i, arg := range args {
+			key, value, found := strings.Cut(arg, "=")
+			if !found || key == "" {
+				common.Fail("Invalid argument %q, expected key=value", arg)
+			}
+
+			value = strings.TrimSpace(value)
+			if value == "" {
+				common.Fail("No value provided for key %q", key)
+			}
+
+			operation := common.BatchOperation{Op: "set", Key: key, Value: value, Position: fmt.Sprintf("argument %d", i+1)}
+			if cmd.Flags().Changed("expires-after") {
+				operation.TTL = msetFlags.expiresAfter.String()
+			}
+
+			operations = append(operations, operation)
+		}
+
+		batch := newBatchApplier(cmd, operations, false)
+		if passwordRequested(cmd) {
+			batch.password = readPassword(cmd, true)
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, operation := range operations {
+				batch.apply(tx, operation)
+			}
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(msetCmd)
+
+	msetCmd.Flags().DurationVar(&msetFlags.expiresAfter, "expires-after", 0, "Expires the values after given duration.")
+	addPasswordFlags(msetCmd, "Password to lock the values")
+}
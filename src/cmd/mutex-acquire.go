@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var mutexAcquireFlags = struct {
+	ttl   time.Duration
+	wait  time.Duration
+	owner string
+}{}
+
+// mutexAcquireCmd represents the mutex acquire command
+var mutexAcquireCmd = &cobra.Command{
+	Use:   "acquire <name>",
+	Short: "Acquire a mutex",
+	Long: `Acquire a mutex for --ttl, waiting up to --wait for it to be released if another owner holds it.
+
+The owner defaults to the host and process ID of the calling shell, so the same script can release the mutex later.
+Acquiring a mutex that is already held by the same owner renews its lease.`,
+	Example: `  # Fail right away if another job holds the mutex
+  kv mutex acquire nightly-backup --ttl 30m
+
+  # Wait up to 1 minute for the mutex
+  kv mutex acquire nightly-backup --ttl 30m --wait 1m`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		owner := mutexAcquireFlags.owner
+		if owner == "" {
+			owner = defaultMutexOwner()
+		}
+
+		acquireMutex(args[0], owner, mutexAcquireFlags.ttl, mutexAcquireFlags.wait)
+	},
+}
+
+func init() {
+	mutexCmd.AddCommand(mutexAcquireCmd)
+
+	mutexAcquireCmd.Flags().DurationVar(&mutexAcquireFlags.ttl, "ttl", 5*time.Minute, "How long the mutex is held unless renewed or released")
+	mutexAcquireCmd.Flags().DurationVar(&mutexAcquireFlags.wait, "wait", 0, "How long to wait for the mutex if it's held")
+	mutexAcquireCmd.Flags().StringVar(&mutexAcquireFlags.owner, "owner", "", "Owner of the mutex, defaults to the host and process ID of the calling shell")
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var mutexListFlags = struct{ output string }{}
+
+// mutexListCmd represents the mutex list command
+var mutexListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List held mutexes",
+	Example: `  # List held mutexes and their owners
+  kv mutex list`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var mutexes []services.Mutex
+		services.RunInTransaction(func(tx *sql.Tx) {
+			mutexes = services.ListMutexes(tx)
+		})
+
+		if len(mutexes) == 0 {
+			common.Stderr.Println("No held mutexes.")
+			return
+		}
+
+		switch mutexListFlags.output {
+		case "yaml":
+			output, _ := yaml.Marshal(mutexes)
+			common.Stdout.Println(string(output))
+		case "json":
+			output, _ := json.MarshalIndent(mutexes, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader([]any{"Name", "Owner", "Acquired At", "Expires At"})
+
+			for _, mutex := range mutexes {
+				t.AppendRow([]any{
+					color.New(color.FgBlue).Sprint(mutex.Name),
+					mutex.Owner,
+					color.New(color.FgGreen).Sprint(mutex.AcquiredAt.Local().Format(time.DateTime)),
+					color.New(color.FgGreen).Sprint(mutex.ExpiresAt.Local().Format(time.DateTime)),
+				})
+			}
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", mutexListFlags.output)
+		}
+	},
+}
+
+func init() {
+	mutexCmd.AddCommand(mutexListCmd)
+
+	mutexListCmd.Flags().StringVarP(&mutexListFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = mutexListCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var mutexReleaseFlags = struct {
+	owner string
+	force bool
+}{}
+
+// mutexReleaseCmd represents the mutex release command
+var mutexReleaseCmd = &cobra.Command{
+	Use:   "release <name>",
+	Short: "Release a mutex",
+	Long: `Release a mutex held by the owner, which defaults to the host and process ID of the calling shell.
+
+Use --force to release a mutex held by another owner.`,
+	Example: `  # Release a mutex acquired earlier by the same script
+  kv mutex release nightly-backup
+
+  # Release a mutex left behind by a stuck job
+  kv mutex release nightly-backup --force`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeMutexArg(toComplete)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		owner := mutexReleaseFlags.owner
+		if owner == "" {
+			owner = defaultMutexOwner()
+		}
+
+		if mutexReleaseFlags.force {
+			owner = ""
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			if services.ReleaseMutex(tx, name, owner) {
+				return
+			}
+
+			holder := services.GetMutex(tx, name)
+			if holder == nil {
+				common.Fail("Mutex %q is not held", name)
+				return // To shut up the compiler
+			}
+
+			common.FailWithCode(common.ExitConflict, "Mutex %q is held by %s, use --force to release it", name, holder.Owner)
+		})
+	},
+}
+
+func init() {
+	mutexCmd.AddCommand(mutexReleaseCmd)
+
+	mutexReleaseCmd.Flags().StringVar(&mutexReleaseFlags.owner, "owner", "", "Owner of the mutex, defaults to the host and process ID of the calling shell")
+	mutexReleaseCmd.Flags().BoolVarP(&mutexReleaseFlags.force, "force", "f", false, "Release the mutex whoever holds it")
+	mutexReleaseCmd.MarkFlagsMutuallyExclusive("owner", "force")
+}
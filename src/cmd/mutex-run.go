@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var mutexRunFlags = struct {
+	ttl  time.Duration
+	wait time.Duration
+}{}
+
+// mutexRunCmd represents the mutex run command
+var mutexRunCmd = &cobra.Command{
+	Use:   "run <name> -- <command> [args...]",
+	Short: "Run a command while holding a mutex",
+	Long: `Acquire a mutex, run a command while holding it, then release it.
+
+The lease is renewed every third of --ttl while the command runs, so the command may run longer than --ttl,
+and the mutex is released soon after --ttl if kv is killed. Interrupt and termination signals are forwarded to the command.
+kv exits with the exit status of the command, or 3 if the mutex is held by another owner.`,
+	Example: `  # Never run two backups at the same time
+  kv mutex run nightly-backup -- ./backup.sh
+
+  # Wait up to 10 minutes for a running backup to finish
+  kv mutex run nightly-backup --wait 10m -- ./backup.sh --full`,
+	Args: cobra.MinimumNArgs(2),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.ArgsLenAtDash() != 1 {
+			common.Fail("Separate the command from the mutex name with --, e.g. kv mutex run <name> -- <command>")
+		}
+
+		name, command := args[0], args[1:]
+		owner := mutexOwner(os.Getpid())
+		ttl := mutexRunFlags.ttl
+
+		acquireMutex(name, owner, ttl, mutexRunFlags.wait)
+
+		release := func() {
+			services.RunInTransaction(func(tx *sql.Tx) { services.ReleaseMutex(tx, name, owner) })
+		}
+
+		child := exec.Command(command[0], command[1:]...)
+		child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+		if err := child.Start(); err != nil {
+			release()
+			common.Fail("Could not run command: %v", err)
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		done := make(chan error, 1)
+		go func() { done <- child.Wait() }()
+
+		renewTicker := time.NewTicker(ttl / 3)
+		defer renewTicker.Stop()
+
+		for {
+			select {
+			case sig := <-signals:
+				_ = child.Process.Signal(sig)
+			case <-renewTicker.C:
+				renewed := false
+				services.RunInTransaction(func(tx *sql.Tx) {
+					renewed = services.RenewMutex(tx, name, owner, time.Now().Add(ttl))
+				})
+
+				if !renewed {
+					common.Warn(fmt.Sprintf("Lost mutex %q, it was released by another owner", name))
+					renewTicker.Stop()
+				}
+			case err := <-done:
+				release()
+
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+
+				common.FailOn(err)
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	mutexCmd.AddCommand(mutexRunCmd)
+
+	mutexRunCmd.Flags().DurationVar(&mutexRunFlags.ttl, "ttl", time.Minute, "Lease of the mutex, renewed while the command runs")
+	mutexRunCmd.Flags().DurationVar(&mutexRunFlags.wait, "wait", 0, "How long to wait for the mutex if it's held")
+}
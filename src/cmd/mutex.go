@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// mutexPollInterval is how often a held mutex is checked while waiting for it
+const mutexPollInterval = 250 * time.Millisecond
+
+// mutexCmd represents the mutex command
+var mutexCmd = &cobra.Command{
+	Use:   "mutex [command]",
+	Short: "Advisory locks to coordinate scripts",
+	Long: `Advisory locks that scripts and cron jobs use so that they never run at the same time.
+
+A mutex is held by an owner, the host and process ID of the script that acquired it, for a limited time (--ttl).
+Leases expire like values with a TTL, so a mutex held by a script that crashed is released once its lease expires.
+'kv mutex run' holds the mutex while running a command, and renews the lease until the command exits.
+
+Commands exit with status 3 if the mutex is held by another owner.`,
+	GroupID: "ttl",
+}
+
+// defaultMutexOwner identifies the process that invoked kv, so that acquire and release calls
+// from the same script are made by the same owner
+func defaultMutexOwner() string {
+	return mutexOwner(os.Getppid())
+}
+
+func mutexOwner(pid int) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	return fmt.Sprintf("%s:%d", host, pid)
+}
+
+// acquireMutex takes the mutex name for owner for ttl, retrying until wait elapses while it is held by another owner
+func acquireMutex(name string, owner string, ttl time.Duration, wait time.Duration) {
+	// Leases are stored with a precision of seconds
+	if ttl < time.Second {
+		common.Fail("Mutex TTL must be at least 1s")
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		var holder services.Mutex
+		services.RunInTransaction(func(tx *sql.Tx) {
+			holder = services.AcquireMutex(tx, name, owner, time.Now().Add(ttl))
+		})
+
+		if holder.Owner == owner {
+			return
+		}
+
+		if !time.Now().Before(deadline) {
+			common.FailWithCode(
+				common.ExitConflict,
+				"Mutex %q is held by %s until %s",
+				name,
+				holder.Owner,
+				holder.ExpiresAt.Local().Format(time.DateTime),
+			)
+		}
+
+		time.Sleep(min(mutexPollInterval, time.Until(deadline)))
+	}
+}
+
+func completeMutexArg(toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var names []cobra.Completion
+	services.RunInTransaction(func(tx *sql.Tx) {
+		for _, mutex := range services.ListMutexes(tx) {
+			names = append(names, mutex.Name)
+		}
+	})
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(mutexCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// otpCmd represents the otp command
+var otpCmd = &cobra.Command{
+	Use:   "otp <key>",
+	Short: "Print the current TOTP code of a key",
+	Long: `Print the current RFC 6238 time-based one-time password generated from the seed stored in a key
+with 'kv set --totp', and how many seconds it stays valid.
+
+The code is printed to stdout and the remaining time to stderr, so the code can be used in scripts.
+Locked keys are decrypted like with 'kv get'.`,
+	Example: `  # Store a seed and print the current code
+  kv set ci-bot.2fa --totp JBSWY3DPEHPK3PXP
+  kv otp ci-bot.2fa
+
+  # Copy the code of a locked seed to the clipboard
+  kv otp ci-bot.2fa --password --clip`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+		var item *services.KVItem
+		var vault *services.Vault
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			key = resolveLinkedKey(tx, key)
+			item = services.GetItem(tx, key)
+			vault = services.FindVault(tx, key)
+		})
+
+		if item == nil {
+			common.Fail("Key %q does not exist", key)
+			return // To shut up the compiler
+		}
+
+		value := readValue(cmd, key, *item, vault)
+		if !common.IsTOTP(value) {
+			common.Fail("Key %q does not hold a TOTP seed, store one with 'kv set --totp'", key)
+		}
+
+		totp, err := common.ParseTOTP(value)
+		if err != nil {
+			common.Fail("Invalid TOTP seed in key %q: %v", key, err)
+		}
+
+		code, remaining := totp.Code(time.Now())
+
+		printValue(cmd, code)
+		common.Stderr.Printf("Valid for %ds\n", int(remaining.Seconds()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+
+	addPasswordFlags(otpCmd, "Password to decrypt the seed if it's encrypted")
+	addClipFlags(otpCmd)
+}
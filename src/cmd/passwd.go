@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"database/sql"
+	"os"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var passwdFlags = struct {
+	prefix          bool
+	all             bool
+	history         bool
+	newPassword     string
+	newPasswordFile string
+}{}
+
+// passwdCmd represents the passwd command
+var passwdCmd = &cobra.Command{
+	Use:   "passwd <key|prefix|key1 key2...>",
+	Short: "Change the password of locked keys",
+	Long: `Change the password of a locked key or multiple locked keys.
+
+The old password is read like any other password (see 'kv get --help'), the new password is given with
+--new-password or --new-password-file flags, or entered interactively.
+
+Values are decrypted and re-encrypted in place in a single transaction, so plain text is never written to the database
+and no history entries are created. If any key fails to decrypt, nothing is changed.
+
+With --history, locked values in older history entries that decrypt with the old password are re-encrypted as well.
+Keys inside vaults and keys locked with --recipient are not affected.`,
+	Example: `  # Change the password of a single key, enter both passwords interactively
+  kv passwd api-key
+
+  # Change the password of all keys with a prefix, including their history
+  kv passwd secrets --prefix --history --password=old --new-password=new
+
+  # Change the password of all locked keys in the store
+  kv passwd --all --password-file ~/.old-password --new-password-file ~/.new-password`,
+	GroupID: "security",
+	Args:    cobra.ArbitraryArgs,
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if passwdFlags.all || passwdFlags.prefix {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if passwdFlags.all && len(args) > 0 {
+			common.Fail("Cannot have arguments with --all")
+		}
+
+		if passwdFlags.prefix {
+			if len(args) == 0 {
+				common.Fail("Prefix must be provided")
+			}
+			if len(args) > 1 {
+				common.Fail("Cannot use --prefix with multiple keys")
+			}
+		}
+
+		if !passwdFlags.all && !passwdFlags.prefix && len(args) == 0 {
+			common.Fail("At least one key must be provided")
+		}
+
+		oldPassword := readPassword(cmd, false)
+		if oldPassword == "" {
+			common.Fail("Password cannot be empty")
+		}
+
+		newPassword := readNewPassword()
+		if newPassword == "" {
+			common.Fail("New password cannot be empty")
+		}
+
+		reencrypt := func(value string) (string, error) {
+			plaintext, err := common.Decrypt(value, oldPassword)
+			if err != nil {
+				return "", err
+			}
+
+			return common.Encrypt(plaintext, newPassword)
+		}
+
+		explicitKeys := !passwdFlags.all && !passwdFlags.prefix
+		var changedKeys []string
+		historyCount := 0
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, key := range selectKeys(tx, args, passwdFlags.all, passwdFlags.prefix) {
+				vault := services.FindVault(tx, key)
+
+				// Explicit keys are validated while re-encrypting, matched keys are skipped when they do not apply
+				if !explicitKeys {
+					item := services.GetItem(tx, key)
+					if item == nil || !item.IsLocked || vault != nil || common.IsRecipientEncrypted(item.Value) {
+						continue
+					}
+				}
+
+				if item := services.GetItem(tx, key); item != nil && common.IsRecipientEncrypted(item.Value) {
+					common.Fail("Key %q is encrypted to public keys and has no password", key)
+				}
+
+				if vault != nil {
+					common.Fail("Key %q belongs to vault %q, its password is the vault's password", key, vault.Prefix)
+				}
+
+				if err := services.ReencryptKey(tx, key, reencrypt); err != nil {
+					if explicitKeys && len(args) == 1 {
+						common.Fail("Wrong password")
+					}
+
+					common.Fail("Wrong password for key %q", key)
+				}
+
+				if passwdFlags.history {
+					historyCount += services.ReencryptHistory(tx, key, reencrypt)
+				}
+
+				changedKeys = append(changedKeys, key)
+			}
+		})
+
+		// Cached derived keys belong to the old password
+		for _, key := range changedKeys {
+			_ = agent.Delete(derivedKeyEntry(key))
+		}
+
+		common.Stdout.Printf("Changed password of %d key(s)\n", len(changedKeys))
+		if passwdFlags.history {
+			common.Stdout.Printf("Re-encrypted %d history record(s)\n", historyCount)
+		}
+	},
+}
+
+// readNewPassword returns the new password from --new-password or --new-password-file flags, or prompts for it
+func readNewPassword() string {
+	switch {
+	case passwdFlags.newPassword == passwordPromptSentinel:
+		return promptPassword(true)
+	case passwdFlags.newPassword != "":
+		return passwdFlags.newPassword
+	case passwdFlags.newPasswordFile != "":
+		content, err := os.ReadFile(common.NormalizePath(passwdFlags.newPasswordFile))
+		if err != nil {
+			common.Fail("Could not read new password file: %v", err)
+		}
+
+		return firstLine(content)
+	default:
+		common.Stderr.Println("Enter the new password")
+		return promptPassword(true)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(passwdCmd)
+
+	addPasswordFlags(passwdCmd, "Current password")
+
+	passwdCmd.Flags().StringVar(&passwdFlags.newPassword, "new-password", "", "New password")
+	passwdCmd.Flags().Lookup("new-password").NoOptDefVal = passwordPromptSentinel
+	passwdCmd.Flags().StringVar(&passwdFlags.newPasswordFile, "new-password-file", "", "Read new password from the first line of given file")
+	passwdCmd.MarkFlagsMutuallyExclusive("new-password", "new-password-file")
+
+	passwdCmd.Flags().BoolVar(&passwdFlags.all, "all", false, "Change password of all locked keys")
+	passwdCmd.Flags().BoolVar(&passwdFlags.prefix, "prefix", false, "Change password of all locked keys with given prefix")
+	passwdCmd.MarkFlagsMutuallyExclusive("all", "prefix")
+
+	passwdCmd.Flags().BoolVar(&passwdFlags.history, "history", false, "Also re-encrypt locked values in older history entries")
+}
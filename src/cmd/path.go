@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// parseDocument parses the value of key as a JSON or YAML document
+func parseDocument(key string, value string) *common.Document {
+	doc, err := common.ParseDocument(value)
+	if err != nil {
+		common.Fail("Value of key %q is not a JSON or YAML object or array", key)
+	}
+
+	return doc
+}
+
+// readPath returns the part of value at path, scalars are returned as plain text
+func readPath(key string, value string, path string) string {
+	doc := parseDocument(key, value)
+
+	node, err := doc.Get(path)
+	if err != nil {
+		common.Fail("Could not read %s of key %q: %v", path, key, err)
+	}
+
+	output, err := doc.FormatNode(node)
+	common.FailOn(err)
+
+	return output
+}
+
+// setPath replaces the part of the value of key at path with value, reading, patching and writing it in one transaction
+// so concurrent changes to the key are not lost. The expiry of the key is kept unless expiresAt is given.
+func setPath(cmd *cobra.Command, key string, path string, value string, expiresAt *time.Time) {
+	var item *services.KVItem
+	var vault *services.Vault
+
+	services.RunInTransaction(func(tx *sql.Tx) {
+		key = resolveLinkedKey(tx, key)
+		item = services.GetItem(tx, key)
+		vault = services.FindVault(tx, key)
+	})
+
+	if item == nil {
+		common.Fail("Key %q does not exist", key)
+		return // To shut up the compiler
+	}
+
+	// Credentials are read before the transaction, so it is not held open while prompting
+	var vaultKey []byte
+	var password string
+	if item.IsLocked {
+		switch {
+		case vault != nil:
+			vaultKey = readVaultKey(cmd, *vault)
+		case common.IsRecipientEncrypted(item.Value):
+			common.Fail("Key %q is encrypted to public keys and cannot be patched, set the whole value instead", key)
+		case !passwordAvailable(cmd):
+			common.Fail("Key is locked, please pass the password with --password flag")
+		default:
+			password = readPassword(cmd, false)
+		}
+	}
+
+	services.RunInTransaction(func(tx *sql.Tx) {
+		item := services.GetItem(tx, key)
+		checkSetConditions(cmd, key, item, vaultKey, password)
+
+		if item == nil {
+			common.Fail("Key %q does not exist", key)
+			return // To shut up the compiler
+		}
+
+		current := item.Value
+		if item.IsLocked {
+			var err error
+			if vaultKey != nil {
+				current, err = common.DecryptWithKey(item.Value, vaultKey)
+			} else {
+				current, err = common.Decrypt(item.Value, password)
+			}
+
+			if err != nil {
+				common.Fail("Wrong password")
+			}
+		}
+
+		doc := parseDocument(key, current)
+		if err := doc.Set(path, value); err != nil {
+			common.Fail("Could not set %s of key %q: %v", path, key, err)
+		}
+
+		patched, err := doc.String()
+		common.FailOn(err)
+
+		if err := common.ValidateValue(item.Type, patched); err != nil {
+			common.Fail("Invalid %s value: %v", item.Type, err)
+		}
+
+		services.EnsureSchemas(tx, key, item.Type, patched)
+
+		if item.IsLocked {
+			if vaultKey != nil {
+				patched, err = common.EncryptWithKey(patched, vaultKey)
+			} else {
+				patched, err = common.Encrypt(patched, password)
+			}
+
+			common.FailOn(err)
+		}
+
+		if expiresAt == nil {
+			expiresAt = item.ExpiresAt
+		}
+
+		services.SetValue(tx, key, patched, expiresAt, item.IsLocked)
+
+		if setFlags.hidden {
+			services.HideKey(tx, key)
+		}
+
+		annotateSetKey(cmd, tx, key)
+	})
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var rekeyFlags = struct {
+	prefix  bool
+	all     bool
+	history bool
+}{}
+
+// rekeyCmd represents the rekey command
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey <key|prefix|key1 key2...>",
+	Short: "Re-encrypt locked keys with the current encryption scheme",
+	Long: `Re-encrypt locked keys that use an older encryption format or weaker key derivation parameters
+than the current ones, keeping the same password.
+
+The key derivation function is configurable using the 'kdf' config (argon2id or pbkdf2).
+Values are re-encrypted in place in a single transaction, no history entries are created.
+Only the latest value of each key is re-encrypted, use --history to also re-encrypt older values in its history.
+Keys that are already up to date, keys inside vaults and keys locked with --recipient are left as they are.
+
+With --all or --prefix, keys that the password does not decrypt are skipped and listed,
+and the command exits with an error once the other keys are re-encrypted.`,
+	Example: `  # Re-encrypt a single key
+  kv rekey api-key --password
+
+  # Re-encrypt all keys with a prefix
+  kv rekey secrets --prefix --password
+
+  # Re-encrypt all locked keys in the store, and their history
+  kv rekey --all --history --password`,
+	GroupID: "security",
+	Args:    cobra.ArbitraryArgs,
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if rekeyFlags.all || rekeyFlags.prefix {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if rekeyFlags.all && len(args) > 0 {
+			common.Fail("Cannot have arguments with --all")
+		}
+
+		if rekeyFlags.prefix {
+			if len(args) == 0 {
+				common.Fail("Prefix must be provided")
+			}
+			if len(args) > 1 {
+				common.Fail("Cannot use --prefix with multiple keys")
+			}
+		}
+
+		if !rekeyFlags.all && !rekeyFlags.prefix && len(args) == 0 {
+			common.Fail("At least one key must be provided")
+		}
+
+		password := readPassword(cmd, false)
+		if password == "" {
+			common.Fail("Password cannot be empty")
+		}
+
+		// Values that are up to date are not re-encrypted
+		errUpToDate := errors.New("up to date")
+		reencrypt := func(value string) (string, error) {
+			outdated, err := common.IsEncryptionOutdated(value)
+			if err != nil {
+				return "", err
+			}
+
+			if !outdated {
+				return "", errUpToDate
+			}
+
+			plaintext, err := common.Decrypt(value, password)
+			if err != nil {
+				return "", err
+			}
+
+			return common.Encrypt(plaintext, password)
+		}
+
+		explicitKeys := !rekeyFlags.all && !rekeyFlags.prefix
+		count, historyCount := 0, 0
+		var skippedKeys []string
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, key := range selectKeys(tx, args, rekeyFlags.all, rekeyFlags.prefix) {
+				item := services.GetItem(tx, key)
+
+				// Explicit keys are validated while re-encrypting, matched keys are skipped when they do not apply
+				if !explicitKeys && (item == nil || !item.IsLocked || services.FindVault(tx, key) != nil || common.IsRecipientEncrypted(item.Value)) {
+					continue
+				}
+
+				if item != nil && common.IsRecipientEncrypted(item.Value) {
+					common.Fail("Key %q is encrypted to public keys and has no password", key)
+				}
+
+				if vault := services.FindVault(tx, key); vault != nil {
+					common.Fail("Key %q belongs to vault %q and cannot be re-encrypted", key, vault.Prefix)
+				}
+
+				switch err := services.ReencryptKey(tx, key, reencrypt); {
+				case err == nil:
+					count++
+				case errors.Is(err, errUpToDate):
+					// Its history may still be outdated
+				case explicitKeys:
+					common.Fail("Wrong password for key %q", key)
+				default:
+					// Matched keys may be locked with other passwords, their history is left as is too
+					skippedKeys = append(skippedKeys, key)
+					continue
+				}
+
+				if rekeyFlags.history {
+					historyCount += services.ReencryptHistory(tx, key, reencrypt)
+				}
+			}
+		})
+
+		common.Stdout.Printf("Re-encrypted %d key(s)\n", count)
+		if rekeyFlags.history {
+			common.Stdout.Printf("Re-encrypted %d history record(s)\n", historyCount)
+		}
+
+		if len(skippedKeys) > 0 {
+			common.Fail("Skipped %d key(s) the password does not decrypt: %s", len(skippedKeys), strings.Join(skippedKeys, ", "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+
+	addPasswordFlags(rekeyCmd, "Encryption password")
+
+	rekeyCmd.Flags().BoolVar(&rekeyFlags.all, "all", false, "Re-encrypt all locked keys")
+	rekeyCmd.Flags().BoolVar(&rekeyFlags.prefix, "prefix", false, "Re-encrypt all locked keys with given prefix")
+	rekeyCmd.MarkFlagsMutuallyExclusive("all", "prefix")
+	rekeyCmd.Flags().BoolVar(&rekeyFlags.history, "history", false, "Also re-encrypt older values in the history of keys")
+}
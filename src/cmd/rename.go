@@ -36,11 +36,20 @@ The old key name will no longer exist after the rename.`,
 		newKey := args[1]
 
 		services.RunInTransaction(func(tx *sql.Tx) {
-			services.RenameKey(tx, oldKey, newKey)
+			renameKey(tx, oldKey, newKey)
 		})
 	},
 }
 
+func renameKey(tx *sql.Tx, oldKey string, newKey string) {
+	// Locked values cannot be checked against schemas
+	if item := services.GetItem(tx, oldKey); item != nil && !item.IsLocked && !item.IsLink {
+		services.EnsureSchemas(tx, newKey, item.Type, item.Value)
+	}
+
+	services.RenameKey(tx, oldKey, newKey)
+}
+
 func init() {
 	rootCmd.AddCommand(renameCmd)
 }
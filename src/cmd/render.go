@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render <template-file|->",
+	Short: "Render a template that references keys",
+	Long: `Render a Go text/template file, or stdin with "-", where {{kv "key"}} is replaced with the value of key.
+
+Referenced values are rendered as templates themselves, so keys can be composed from other keys,
+and reference cycles are reported as errors. See also 'kv get --render'.
+
+Locked keys are decrypted with the password given with the usual flags (see 'kv get --help'),
+keys it does not decrypt are prompted for interactively.`,
+	Example: `  # Compose a key from other keys
+  kv set db.url 'postgres://{{kv "db.user"}}:{{kv "db.pass"}}@{{kv "db.host"}}/app'
+  kv get db.url --render
+
+  # Generate a config file from a template
+  kv render config.tmpl > config.env
+
+  # Render a template from stdin
+  echo 'TOKEN={{kv "api-token"}}' | kv render - --password`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var content []byte
+		var err error
+
+		if args[0] == "-" {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(common.NormalizePath(args[0]))
+		}
+
+		if err != nil {
+			common.Fail("Could not read template: %v", err)
+		}
+
+		renderer := newTemplateRenderer(cmd)
+		output, err := renderer.render(args[0], string(content), nil)
+		if err != nil {
+			common.Fail("Could not render template: %v", err)
+		}
+
+		// Written as is, the logger would add a newline the template may not have
+		_, _ = io.WriteString(common.Stdout.Writer(), output)
+	},
+}
+
+// templateRenderer renders values and files as templates, resolving {{kv "key"}} references from the store
+type templateRenderer struct {
+	cmd    *cobra.Command
+	values map[string]string
+
+	// reader holds the password and vault keys, read once for all references
+	reader *valueReader
+}
+
+func newTemplateRenderer(cmd *cobra.Command) *templateRenderer {
+	return &templateRenderer{cmd: cmd, values: map[string]string{}, reader: newValueReader(cmd)}
+}
+
+// render executes text as a template, stack holds the keys being rendered to detect reference cycles
+func (r *templateRenderer) render(name string, text string, stack []string) (string, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"kv": func(key string) (string, error) { return r.lookup(key, stack) },
+		}).
+		Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	if err := tmpl.Execute(&output, nil); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
+
+// lookup returns the rendered value of a referenced key
+func (r *templateRenderer) lookup(key string, stack []string) (string, error) {
+	services.RunInTransaction(func(tx *sql.Tx) {
+		key = resolveLinkedKey(tx, key)
+	})
+
+	// Cycles and cached values are checked on the resolved key, so links cannot hide a cycle
+	if slices.Contains(stack, key) {
+		return "", fmt.Errorf("reference cycle %s", strings.Join(append(slices.Clone(stack), key), " -> "))
+	}
+
+	if value, found := r.values[key]; found {
+		return value, nil
+	}
+
+	var item *services.KVItem
+	var vault *services.Vault
+	services.RunInTransaction(func(tx *sql.Tx) {
+		item = services.GetItem(tx, key)
+		vault = services.FindVault(tx, key)
+	})
+
+	if item == nil {
+		return "", fmt.Errorf("key %q does not exist", key)
+	}
+
+	value, err := r.render(key, r.decrypt(key, *item, vault), append(slices.Clone(stack), key))
+	if err != nil {
+		return "", err
+	}
+
+	r.values[key] = value
+	return value, nil
+}
+
+// decrypt returns the plain value of a referenced key, prompting for its password if the given one does not decrypt it
+func (r *templateRenderer) decrypt(key string, item services.KVItem, vault *services.Vault) string {
+	if !item.IsLocked {
+		return item.Value
+	}
+
+	if vault != nil || common.IsRecipientEncrypted(item.Value) {
+		return r.reader.read(key, item, vault)
+	}
+
+	if value, found := decryptWithCachedKey(key, item.Value); found {
+		return value
+	}
+
+	// The given password is read once, and tried on every locked reference
+	if r.reader.password != nil || passwordAvailable(r.cmd) {
+		password := r.reader.readPassword()
+		if value, err := common.Decrypt(item.Value, password); err == nil {
+			cacheDerivedKey(key, item.Value, password)
+			return value
+		}
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		common.Fail("Key %q is locked, please pass its password with --password flag", key)
+	}
+
+	password := readPasswordFromTerminal(fmt.Sprintf("Password for %q", key))
+	value, err := common.Decrypt(item.Value, password)
+	if err != nil {
+		common.Fail("Wrong password for key %q", key)
+	}
+
+	cacheDerivedKey(key, item.Value, password)
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	addPasswordFlags(renderCmd, "Password to decrypt locked keys")
+}
@@ -20,7 +20,9 @@ var restoreCmd = &cobra.Command{
 	Short: "Restore DB backup",
 	Long: `Restore database from backup, completely replacing any existing data (and history).
 
-The backup file must be a valid database file created with the 'backup' command. Backups are generally forward-compatible, so any backup created by an older version is compatible with newer versions, but not the other way around.
+The backup file must be a valid database file created with the 'backup' command.
+Restoring an encrypted backup makes the database encrypted with the backup's password, restoring a plain backup into
+an encrypted database keeps it encrypted. Backups are generally forward-compatible, so any backup created by an older version is compatible with newer versions, but not the other way around.
 
 `,
 
@@ -62,11 +64,28 @@ cat backup.db | kv db restore --stdin`,
 			common.Fail("Could not read %q: %v", backupPath, err)
 		}
 
-		// Validate backup is a valid SQLite database
-		if err := common.ValidateSqliteFile(backupPath); err != nil {
+		// Validate backup is a valid SQLite database, or an encrypted one that can be decrypted
+		backupEncrypted := common.IsEncryptedDBFile(backupPath)
+		if backupEncrypted {
+			if err := common.ValidateEncryptedDBFile(backupPath); err != nil {
+				common.Fail("Invalid backup file: %v", err)
+			}
+		} else if err := common.ValidateSqliteFile(backupPath); err != nil {
 			common.Fail("Invalid backup file: %v", err)
 		}
 
+		// A plain backup restored into an encrypted database is encrypted with the current master key
+		var wrappedKey string
+		var masterKey []byte
+		if common.IsDBEncrypted() && !backupEncrypted {
+			_, err := common.GetDB()
+			if err != nil {
+				common.Fail("Could not open database: %v", err)
+			}
+
+			wrappedKey, masterKey = common.GetDBMasterKey()
+		}
+
 		// Create temp backup in case DB restoration fails
 		tempBackupFile, err := os.CreateTemp("", "kv-temp-backup")
 		common.FailOn(err)
@@ -84,36 +103,61 @@ cat backup.db | kv db restore --stdin`,
 		// Close database connection
 		common.CloseDB()
 
-		// Remove current database and remove WAL files
+		// The temp backup is in the current database's format
 		dbPath := common.GetDBPath()
-		_ = os.Remove(dbPath)
-		_ = os.Remove(dbPath + "-wal")
-		_ = os.Remove(dbPath + "-shm")
+		currentPath := dbPath
+		if common.IsDBEncrypted() {
+			currentPath = common.GetEncryptedDBPath()
+		}
 
-		// Copy backup into DB file
-		err = common.CopyFile(backupPath, dbPath)
-		if err != nil {
-			// Restore backup
-			_ = os.Remove(dbPath)
-			_ = os.Rename(tempBackupFile.Name(), dbPath)
+		rollback := func(err error) {
+			common.CloseDB()
+			removeDBFiles()
+			_ = os.Rename(tempBackupFile.Name(), currentPath)
 
 			common.Fail("Failed to restore database: %v", err)
 		}
 
+		// Remove current database and remove WAL files
+		removeDBFiles()
+
+		// Copy backup into DB file, encrypted backups replace the encrypted database file
+		restorePath := dbPath
+		if backupEncrypted {
+			restorePath = common.GetEncryptedDBPath()
+		}
+
+		err = common.CopyFile(backupPath, restorePath)
+		if err != nil {
+			rollback(err)
+		}
+
 		// Reopen database to make sure migrations succeed
 		_, err = common.GetDB()
 		if err != nil {
-			// Restore backup
-			_ = os.Remove(dbPath)
-			_ = os.Rename(tempBackupFile.Name(), dbPath)
+			rollback(err)
+		}
 
-			common.Fail("Failed to restore database: %v", err)
+		if masterKey != nil {
+			err = common.EncryptDBWithKey(wrappedKey, masterKey)
+			if err != nil {
+				rollback(err)
+			}
 		}
 
 		fmt.Println("Database restored from backup successfully")
 	},
 }
 
+// removeDBFiles removes the database in any of its forms, along with WAL files
+func removeDBFiles() {
+	dbPath := common.GetDBPath()
+	_ = os.Remove(dbPath)
+	_ = os.Remove(dbPath + "-wal")
+	_ = os.Remove(dbPath + "-shm")
+	_ = os.Remove(common.GetEncryptedDBPath())
+}
+
 func init() {
 	dbCmd.AddCommand(restoreCmd)
 
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <key>",
+	Short: "Replace the value of a key with a newly generated one",
+	Long: `Replace the value of an existing key with a newly generated random value, see 'kv gen' for the generation options.
+
+The old value is kept in history, and the rotation time is recorded so stale secrets can be found
+with 'kv list --stale'. Hidden state and expiration of the key are kept.
+
+Locked keys stay locked with the same password, which is verified against the current value.
+Keys inside a vault are encrypted with the vault key. Keys locked with --recipient cannot be rotated.`,
+	Example: `  # Rotate a token
+  kv rotate api-token
+
+  # Rotate a locked key, and copy the new value to the clipboard
+  kv rotate signing-key --charset hex --length 64 --password --clip
+
+  # Find secrets that were not rotated in the last 90 days
+  kv list --stale 90d`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		var item *services.KVItem
+		var vault *services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			key = resolveLinkedKey(tx, key)
+			item = services.GetItem(tx, key)
+			vault = services.FindVault(tx, key)
+		})
+
+		if item == nil {
+			common.Fail("Key %q does not exist", key)
+			return // To shut up the compiler
+		}
+
+		if item.IsLocked && common.IsRecipientEncrypted(item.Value) {
+			common.Fail("Key %q is encrypted to public keys and cannot be rotated", key)
+		}
+
+		value := generateValue(cmd)
+		storedValue := value
+		password := ""
+
+		switch {
+		case vault != nil:
+			var err error
+			storedValue, err = common.EncryptWithKey(value, readVaultKey(cmd, *vault))
+			common.FailOn(err)
+		case item.IsLocked:
+			password = readPassword(cmd, false)
+			if _, err := common.Decrypt(item.Value, password); err != nil {
+				common.Fail("Wrong password")
+			}
+
+			var err error
+			storedValue, err = common.Encrypt(value, password)
+			common.FailOn(err)
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			current := services.GetItem(tx, key)
+			if current == nil || current.Value != item.Value {
+				common.Fail("Key %q was changed while rotating it, try again", key)
+			}
+
+			// Validated before encryption, as locked values cannot be validated later
+			if err := common.ValidateValue(current.Type, value); err != nil {
+				common.Fail("Invalid %s value: %v", current.Type, err)
+			}
+
+			services.EnsureSchemas(tx, key, current.Type, value)
+
+			services.SetValue(tx, key, storedValue, item.ExpiresAt, vault != nil || item.IsLocked)
+			services.MarkRotated(tx, key)
+		})
+
+		// The new value has a new salt, so the cached key of the old one no longer applies
+		if password != "" {
+			cacheDerivedKey(key, storedValue, password)
+		}
+
+		common.Stderr.Printf("Rotated %q\n", key)
+
+		if clipRequested(cmd) {
+			copyToClipboard(cmd, value)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rotateCmd)
+
+	addGenerateFlags(rotateCmd)
+	addPasswordFlags(rotateCmd, "Password of the locked key")
+	addClipFlags(rotateCmd)
+}
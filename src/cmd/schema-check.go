@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var schemaCheckFlags = struct{ output string }{}
+
+// schemaViolation is an existing value that does not validate against its schemas
+type schemaViolation struct {
+	Key   string `json:"key" yaml:"key"`
+	Error string `json:"error" yaml:"error"`
+}
+
+// schemaCheckCmd represents the schema check command
+var schemaCheckCmd = &cobra.Command{
+	Use:   "check [prefix]",
+	Short: "Report existing values that do not match their schemas",
+	Long: `Validate existing values under prefix, or all values, against the schemas of the prefixes they are under.
+
+Exits with a non-zero status if any value does not validate. Locked values and links are skipped.`,
+	Example: `  # Check all values
+  kv schema check
+
+  # Check values under a prefix, as JSON
+  kv schema check app. -o json`,
+	Args: cobra.MaximumNArgs(1),
+
+	ValidArgsFunction: completeSchemaArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var prefix string
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		var violations []schemaViolation
+		services.RunInTransaction(func(tx *sql.Tx) {
+			violations = findSchemaViolations(tx, prefix)
+		})
+
+		if len(violations) == 0 {
+			common.Stderr.Println("All values match their schemas.")
+			return
+		}
+
+		switch schemaCheckFlags.output {
+		case "yaml":
+			output, _ := yaml.Marshal(violations)
+			common.Stdout.Println(string(output))
+		case "json":
+			output, _ := json.MarshalIndent(violations, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader([]any{"Key", "Error"})
+
+			for _, violation := range violations {
+				t.AppendRow([]any{
+					color.New(color.FgBlue).Sprint(violation.Key),
+					color.New(color.FgRed).Sprint(violation.Error),
+				})
+			}
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", schemaCheckFlags.output)
+		}
+
+		common.Fail("")
+	},
+}
+
+// findSchemaViolations validates plain values under prefix against their schemas
+func findSchemaViolations(tx *sql.Tx, prefix string) []schemaViolation {
+	var violations []schemaViolation
+
+	for _, item := range services.ListItems(tx, prefix, services.MatchExisting) {
+		// Prefix listing is case-insensitive, schema prefixes are not
+		if item.IsLocked || item.IsLink || !strings.HasPrefix(item.Key, prefix) {
+			continue
+		}
+
+		if err := services.ValidateSchemas(tx, item.Key, item.Type, item.Value); err != nil {
+			violations = append(violations, schemaViolation{Key: item.Key, Error: err.Error()})
+		}
+	}
+
+	return violations
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaCheckCmd)
+
+	schemaCheckCmd.Flags().StringVarP(&schemaCheckFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = schemaCheckCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
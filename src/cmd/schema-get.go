@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// schemaGetCmd represents the schema get command
+var schemaGetCmd = &cobra.Command{
+	Use:   "get <prefix>",
+	Short: "Print the JSON Schema of a prefix",
+	Example: `  # Print the schema of "app."
+  kv schema get app.`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: completeSchemaArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var schema *services.Schema
+		services.RunInTransaction(func(tx *sql.Tx) {
+			schema = services.GetSchema(tx, args[0])
+		})
+
+		if schema == nil {
+			common.Fail("Schema %q does not exist", args[0])
+			return // To shut up the compiler
+		}
+
+		common.Stdout.Println(strings.TrimSpace(schema.Document))
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaGetCmd)
+}
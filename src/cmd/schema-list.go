@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var schemaListFlags = struct{ output string }{}
+
+// schemaListCmd represents the schema list command
+var schemaListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List prefixes with a JSON Schema",
+	Example: `  # List schemas
+  kv schema list
+
+  # List schemas with their documents as JSON
+  kv schema list --output json`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var schemas []services.Schema
+		services.RunInTransaction(func(tx *sql.Tx) {
+			schemas = services.ListSchemas(tx)
+		})
+
+		if len(schemas) == 0 {
+			common.Stderr.Println("No schemas. Use `kv schema set` to add one.")
+			return
+		}
+
+		switch schemaListFlags.output {
+		case "yaml":
+			output, _ := yaml.Marshal(schemas)
+			common.Stdout.Println(string(output))
+		case "json":
+			output, _ := json.MarshalIndent(schemas, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader([]any{"Prefix", "Created At"})
+
+			for _, schema := range schemas {
+				t.AppendRow([]any{
+					color.New(color.FgBlue).Sprint(schema.Prefix),
+					color.New(color.FgGreen).Sprint(schema.CreatedAt.Local().Format(time.DateTime)),
+				})
+			}
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", schemaListFlags.output)
+		}
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaListCmd)
+
+	schemaListCmd.Flags().StringVarP(&schemaListFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = schemaListCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
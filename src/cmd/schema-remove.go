@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// schemaRemoveCmd represents the schema remove command
+var schemaRemoveCmd = &cobra.Command{
+	Use:     "remove <prefix>",
+	Aliases: []string{"rm"},
+	Short:   "Remove the JSON Schema of a prefix",
+	Example: `  # Stop validating values under "app."
+  kv schema remove app.`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: completeSchemaArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		services.RunInTransaction(func(tx *sql.Tx) {
+			services.DeleteSchema(tx, args[0])
+		})
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaRemoveCmd)
+}
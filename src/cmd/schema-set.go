@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// schemaSetCmd represents the schema set command
+var schemaSetCmd = &cobra.Command{
+	Use:   "set <prefix> <schema-file|->",
+	Short: "Attach a JSON Schema to a prefix",
+	Long: `Attach a JSON Schema document to a prefix, replacing its previous schema if any.
+
+The schema is read from the given file, or from stdin with "-". Existing values are not changed,
+use 'kv schema check' to find values that do not validate.`,
+	Example: `  # Values under "app." must validate against schema.json
+  kv schema set app. schema.json
+
+  # Ports must be valid integers
+  echo '{"type": "integer", "minimum": 1, "maximum": 65535}' | kv schema set app.port -`,
+	Args: cobra.ExactArgs(2),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeKeyArg(toComplete, services.MatchExisting)
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := args[0]
+
+		var document []byte
+		var err error
+		if args[1] == "-" {
+			document, err = io.ReadAll(os.Stdin)
+		} else {
+			document, err = os.ReadFile(common.NormalizePath(args[1]))
+		}
+
+		if err != nil {
+			common.Fail("Could not read schema: %v", err)
+		}
+
+		if _, err := common.CompileSchema(string(document)); err != nil {
+			common.Fail("Invalid schema: %v", err)
+		}
+
+		violations := 0
+		services.RunInTransaction(func(tx *sql.Tx) {
+			services.SetSchema(tx, prefix, string(document))
+			violations = len(findSchemaViolations(tx, prefix))
+		})
+
+		if violations > 0 {
+			common.Warn(fmt.Sprintf("%d existing value(s) do not match the schema, see 'kv schema check'", violations))
+		}
+	},
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaSetCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema [command]",
+	Short: "Validate values under a prefix with JSON Schema",
+	Long: `Attach JSON Schema documents to prefixes, so that values written under them must validate.
+
+Writes with 'set', 'copy' and 'rename' that do not validate against the schemas of all prefixes
+the key is under are rejected. Typed values are validated as their type, e.g. an int as a number,
+and untyped values as JSON if they parse as JSON, or as strings otherwise.
+
+Locked values cannot be read without their password, so they are only validated when set.`,
+	GroupID: "kv",
+}
+
+// completeSchemaArg completes the first argument with existing schema prefixes
+func completeSchemaArg(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var prefixes []string
+	services.RunInTransaction(func(tx *sql.Tx) {
+		for _, schema := range services.ListSchemas(tx) {
+			prefixes = append(prefixes, schema.Prefix)
+		}
+	})
+
+	return prefixes, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
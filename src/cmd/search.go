@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var searchFlags = struct {
+	in     []string
+	show   bool
+	output string
+}{}
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search keys, values and descriptions",
+	Long: `Search for keys, values and descriptions containing the query, ignoring case.
+
+By default keys, current values and descriptions are searched, use --in to choose from:
+keys, values, desc (descriptions, see 'kv annotate') and history (older values of keys, see 'kv history').
+
+Locked values are never searched, and hidden values only with --show.
+Matches are highlighted in table output, and values are shortened around the first match.
+
+Searches use a full-text index that is kept up to date on every write.
+Queries shorter than 3 characters cannot use the index, and are slower on large stores.`,
+	Example: `  # Search keys, values and descriptions
+  kv search postgres
+
+  # Only search descriptions
+  kv search "RDS master" --in desc
+
+  # Search current and older values, including hidden ones
+  kv search localhost --in values,history --show
+
+  # Search as JSON
+  kv search token -o json`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		query := args[0]
+		if strings.TrimSpace(query) == "" {
+			common.Fail("Query cannot be empty")
+		}
+
+		in := []string{services.SearchInKeys, services.SearchInValues, services.SearchInDescriptions}
+		if cmd.Flags().Changed("in") {
+			in = nil
+			for _, place := range searchFlags.in {
+				if !slices.Contains(services.SearchPlaces, place) {
+					common.Fail("Invalid place %q, options: %s", place, strings.Join(services.SearchPlaces, ", "))
+				}
+
+				if !slices.Contains(in, place) {
+					in = append(in, place)
+				}
+			}
+		}
+
+		var results []services.SearchResult
+		services.RunInTransaction(func(tx *sql.Tx) {
+			results = services.Search(tx, query, in, searchFlags.show)
+		})
+
+		switch searchFlags.output {
+		case "yaml":
+			if len(results) == 0 {
+				results = []services.SearchResult{}
+			}
+
+			output, _ := yaml.Marshal(results)
+			common.Stdout.Print(string(output))
+		case "json":
+			if len(results) == 0 {
+				results = []services.SearchResult{}
+			}
+
+			output, _ := json.MarshalIndent(results, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			if len(results) == 0 {
+				common.Stderr.Println("No matches.")
+				return
+			}
+
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader(table.Row{"Key", "In", "Match", "Timestamp"})
+
+			pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+			for _, result := range results {
+				text := result.Text
+				if result.In == services.SearchInKeys {
+					text = ""
+				}
+
+				t.AppendRow(table.Row{
+					highlightMatches(pattern, result.Key, color.New(color.FgBlue)),
+					color.New(color.FgMagenta).Sprint(result.In),
+					highlightMatches(pattern, snippet(pattern, text, searchSnippetLength), nil),
+					color.New(color.FgGreen).Sprint(result.Timestamp.Local().Format(time.DateTime)),
+				})
+			}
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", searchFlags.output)
+		}
+	},
+}
+
+// searchSnippetLength is the number of characters of matched values shown in table output
+const searchSnippetLength = 60
+
+// snippet returns text on a single line, shortened to about length characters around the first match of pattern
+func snippet(pattern *regexp.Regexp, text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	start := 0
+	if match := pattern.FindStringIndex(text); match != nil {
+		matchStart := len([]rune(text[:match[0]]))
+		matchLength := len([]rune(text[match[0]:match[1]]))
+		start = max(0, min(matchStart-(length-matchLength)/2, len(runes)-length))
+	}
+
+	end := min(len(runes), start+length)
+
+	shortened := string(runes[start:end])
+	if start > 0 {
+		shortened = "…" + shortened
+	}
+
+	if end < len(runes) {
+		shortened += "…"
+	}
+
+	return shortened
+}
+
+// highlightMatches colors the matches of pattern in text, and the rest of text with base if given
+func highlightMatches(pattern *regexp.Regexp, text string, base *color.Color) string {
+	highlight := color.New(color.FgBlack, color.BgYellow)
+
+	var output strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		output.WriteString(colorize(base, text[last:match[0]]))
+		output.WriteString(highlight.Sprint(text[match[0]:match[1]]))
+		last = match[1]
+	}
+
+	output.WriteString(colorize(base, text[last:]))
+	return output.String()
+}
+
+func colorize(c *color.Color, text string) string {
+	if c == nil || text == "" {
+		return text
+	}
+
+	return c.Sprint(text)
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringSliceVar(&searchFlags.in, "in", nil, "Where to search, options: keys, values, desc, history (default keys,values,desc)")
+	_ = searchCmd.RegisterFlagCompletionFunc(
+		"in",
+		cobra.FixedCompletions(services.SearchPlaces, cobra.ShellCompDirectiveDefault),
+	)
+	searchCmd.Flags().BoolVarP(&searchFlags.show, "show", "s", false, "Also search hidden values")
+
+	searchCmd.Flags().StringVarP(&searchFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = searchCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
@@ -15,6 +15,16 @@ import (
 var setFlags = struct {
 	expiresAfter time.Duration
 	hidden       bool
+	totp         bool
+	valueType    string
+	path         string
+	description  string
+	tags         []string
+
+	ifVersion int64
+	ifValue   string
+	ifAbsent  bool
+	ifExists  bool
 }{}
 
 // setCmd represents the set command
@@ -27,7 +37,34 @@ Optionally set automatic expiration using --expires-after with duration suffixes
   s (second), m (minute), h (hour)
   Example durations: 1h, 30m, 10s, 2h3m4s
 
-Providing a negative duration expires the key immediately.`,
+Providing a negative duration expires the key immediately.
+
+Setting a link replaces the link itself with the value, use 'kv link' to point it elsewhere.
+Unlike set, --path, 'kv incr', 'kv append' and 'kv rotate' change the value of the link's target.
+
+Keys set inside a vault are always encrypted with the vault key, see 'kv vault'.
+
+With --type, the value is validated as one of: string, int, bool, json, url (absolute) or duration,
+and the type is kept for following values of the key until another type is given.
+Typed values are output as numbers, booleans or objects by 'kv list -o json|yaml'.
+
+Values under a prefix with a JSON Schema must validate against it, see 'kv schema'.
+
+With --totp, the value is a TOTP seed, either an otpauth://totp URI or a base32 secret,
+and codes are generated from it with 'kv otp'.
+
+With --path, only the part of a JSON or YAML value at given path (e.g. .server.port or .hosts[0]) is replaced,
+the value is read, patched and written in a single transaction. The new value is parsed as JSON (or YAML for YAML values),
+and set as a string if it does not parse. Missing keys along the path are created, the key stays locked if it was,
+and its expiry is kept unless --expires-after is given. Comments in YAML values are kept, including those of
+the replaced part, but the value is written back with 2-space indentation.
+
+Conditional writes only store the value if the key is at a given version (--if-version, 0 for absent keys),
+holds a given value (--if-value), does not exist (--if-absent) or exists (--if-exists).
+The condition is checked in the same transaction as the write, and the command exits with status 3 if it does not hold.
+Versions are shown by 'kv get --meta' and 'kv list -o json', and change whenever the value changes.
+
+With --desc and --tag, the key is described and tagged along with setting its value, see 'kv annotate'.`,
 	Example: `  # Store a simple key-value pair
   kv set api-key "sk-1234567890"
 
@@ -44,7 +81,25 @@ Providing a negative duration expires the key immediately.`,
   echo "line 1\nline 2" | kv set my-config
 
   # Store JSON configuration
-  kv set app.config '{"port": 8080, "debug": true}'`,
+  kv set app.config '{"port": 8080, "debug": true}'
+
+  # Change a single field of a JSON value
+  kv set app.config --path .server.port 9090
+
+  # Store a locked value with a description and tags
+  kv set db.pass "s3cr3t" --password --desc "prod RDS master" --tag prod --tag db
+
+  # Store a typed value, following values of the key must be integers as well
+  kv set port 8080 --type int
+
+  # Only update the value if nobody changed it since it was read at version 42
+  kv set app.config '{"port": 9090}' --if-version 42
+
+  # Create a key only if it does not exist yet
+  kv set deploy.owner "$USER" --if-absent
+
+  # Store a TOTP seed for 2FA codes, locked with a password
+  kv set ci-bot.2fa --totp 'otpauth://totp/CI:bot?secret=JBSWY3DPEHPK3PXP&issuer=CI' --password`,
 	GroupID: "kv",
 	Args:    cobra.RangeArgs(1, 2),
 
@@ -57,6 +112,12 @@ Providing a negative duration expires the key immediately.`,
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := common.ValidateType(setFlags.valueType); err != nil {
+			common.Fail("Invalid type: %v", err)
+		}
+
+		validateTags(setFlags.tags)
+
 		key := args[0]
 		value := ""
 		if len(args) == 2 {
@@ -80,23 +141,74 @@ Providing a negative duration expires the key immediately.`,
 			common.Fail("No value provided")
 		}
 
-		var password string
-		passwordFlag := cmd.Flags().Lookup("password")
-		if passwordFlag.Changed {
-			password = readPassword(cmd, true)
+		if cmd.Flags().Changed("path") {
+			setPath(cmd, key, setFlags.path, value, expiresAt)
+			return
+		}
+
+		if setFlags.totp {
+			var err error
+			value, err = common.NormalizeTOTP(value, key)
+			if err != nil {
+				common.Fail("Invalid TOTP seed: %v", err)
+			}
 		}
 
-		if password != "" {
+		var vault *services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			vault = services.FindVault(tx, key)
+		})
+
+		// Validated in the write transaction, against the plain value as locked values cannot be validated
+		plainValue := value
+		isLocked := false
+
+		var vaultKey []byte
+		var password string
+		if vault != nil {
+			vaultKey = readVaultKey(cmd, *vault)
+
 			var err error
-			value, err = common.Encrypt(value, password)
+			value, err = common.EncryptWithKey(value, vaultKey)
 			common.FailOn(err)
+
+			isLocked = true
+		} else if passwordRequested(cmd) {
+			password = readPassword(cmd, true)
+			if password != "" {
+				var err error
+				value, err = common.Encrypt(value, password)
+				common.FailOn(err)
+
+				isLocked = true
+			}
 		}
 
 		services.RunInTransaction(func(tx *sql.Tx) {
-			services.SetValue(tx, key, value, expiresAt, password != "")
+			item := services.GetItem(tx, key)
+			checkSetConditions(cmd, key, item, vaultKey, password)
+
+			valueType := setFlags.valueType
+			if item != nil && !cmd.Flags().Changed("type") {
+				valueType = item.Type
+			}
+
+			if err := common.ValidateValue(valueType, plainValue); err != nil {
+				common.Fail("Invalid %s value: %v", valueType, err)
+			}
+
+			services.EnsureSchemas(tx, key, valueType, plainValue)
+
+			services.SetValue(tx, key, value, expiresAt, isLocked)
+			if cmd.Flags().Changed("type") {
+				services.SetValueType(tx, key, valueType)
+			}
+
 			if setFlags.hidden {
 				services.HideKey(tx, key)
 			}
+
+			annotateSetKey(cmd, tx, key)
 		})
 	},
 }
@@ -105,7 +217,89 @@ func init() {
 	rootCmd.AddCommand(setCmd)
 
 	setCmd.Flags().DurationVar(&setFlags.expiresAfter, "expires-after", 0, "Expires this value after given duration.")
-	setCmd.Flags().StringP("password", "p", "", "Password to lock this value")
-	setCmd.Flags().Lookup("password").NoOptDefVal = passwordPromptSentinel
+	addPasswordFlags(setCmd, "Password to lock this value")
 	setCmd.Flags().BoolVar(&setFlags.hidden, "hidden", false, "Mark key as hidden")
+	setCmd.Flags().StringVarP(&setFlags.valueType, "type", "t", "", "Type of the value, options: string, int, bool, json, url, duration")
+	_ = setCmd.RegisterFlagCompletionFunc(
+		"type",
+		cobra.FixedCompletions(common.ValueTypes, cobra.ShellCompDirectiveDefault),
+	)
+	setCmd.Flags().BoolVar(&setFlags.totp, "totp", false, "Value is a TOTP seed (otpauth URI or base32 secret), see 'kv otp'")
+	setCmd.Flags().StringVar(&setFlags.path, "path", "", "Only replace the part of a JSON or YAML value at given path, e.g. .server.port")
+	setCmd.MarkFlagsMutuallyExclusive("path", "totp")
+	setCmd.MarkFlagsMutuallyExclusive("path", "type")
+
+	setCmd.Flags().StringVar(&setFlags.description, "desc", "", "Description of the key, see 'kv annotate'")
+	addTagFlag(setCmd, &setFlags.tags, "tag", "Add given tag to the key, can be repeated")
+
+	setCmd.Flags().Int64Var(&setFlags.ifVersion, "if-version", 0, "Only set if the key is at given version, 0 if it must not exist")
+	setCmd.Flags().StringVar(&setFlags.ifValue, "if-value", "", "Only set if the key holds given value")
+	setCmd.Flags().BoolVar(&setFlags.ifAbsent, "if-absent", false, "Only set if the key does not exist")
+	setCmd.Flags().BoolVar(&setFlags.ifExists, "if-exists", false, "Only set if the key exists")
+	setCmd.MarkFlagsMutuallyExclusive("if-absent", "if-exists")
+	setCmd.MarkFlagsMutuallyExclusive("if-absent", "if-value")
+}
+
+// annotateSetKey sets the description and adds the tags given to set, if any
+func annotateSetKey(cmd *cobra.Command, tx *sql.Tx, key string) {
+	if cmd.Flags().Changed("desc") {
+		services.SetDescription(tx, key, setFlags.description)
+	}
+
+	services.AddTags(tx, key, setFlags.tags...)
+}
+
+// checkSetConditions fails with common.ExitConflict unless item, the current item of key, satisfies the --if-* flags of set.
+// Locked values are decrypted for --if-value with vaultKey or password, whichever is given.
+func checkSetConditions(cmd *cobra.Command, key string, item *services.KVItem, vaultKey []byte, password string) {
+	flags := cmd.Flags()
+
+	if setFlags.ifAbsent && item != nil {
+		common.FailWithCode(common.ExitConflict, "Key %q already exists", key)
+	}
+
+	if setFlags.ifExists && item == nil {
+		common.FailWithCode(common.ExitConflict, "Key %q does not exist", key)
+	}
+
+	if flags.Changed("if-version") {
+		var version int64
+		if item != nil {
+			version = item.Version
+		}
+
+		if version != setFlags.ifVersion {
+			common.FailWithCode(common.ExitConflict, "Key %q is at version %d, not %d", key, version, setFlags.ifVersion)
+		}
+	}
+
+	if flags.Changed("if-value") {
+		if item == nil {
+			common.FailWithCode(common.ExitConflict, "Key %q does not exist", key)
+			return // To shut up the compiler
+		}
+
+		current := item.Value
+		if item.IsLocked {
+			var err error
+			switch {
+			case vaultKey != nil:
+				current, err = common.DecryptWithKey(item.Value, vaultKey)
+			case common.IsRecipientEncrypted(item.Value):
+				current = decryptWithIdentity(key, item.Value)
+			case password != "":
+				current, err = common.Decrypt(item.Value, password)
+			default:
+				common.Fail("Key %q is locked, pass its password to compare its value", key)
+			}
+
+			if err != nil {
+				common.Fail("Could not decrypt key %q to compare its value", key)
+			}
+		}
+
+		if current != setFlags.ifValue {
+			common.FailWithCode(common.ExitConflict, "Value of key %q does not match", key)
+		}
+	}
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var statFlags = struct{ output string }{}
+
+// statCmd represents the stat command
+var statCmd = &cobra.Command{
+	Use:   "stat <key>",
+	Short: "Show the metadata of a key",
+	Long: `Show the metadata of a key without its value: description, tags, type, lock and hidden state, link target, vault,
+size of the stored value in bytes (of the encrypted value for locked keys), current version, number of versions in history,
+creation and modification times, expiry and rotation time.
+
+The creation time is that of the oldest history record kept, see 'kv history'.
+Links are not followed, their target is shown instead.`,
+	Example: `  # Show the metadata of a key
+  kv stat api-key
+
+  # Show the metadata of a key as JSON
+  kv stat api-key -o json`,
+	GroupID: "kv",
+	Args:    cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+
+		var stats *services.KeyStats
+		services.RunInTransaction(func(tx *sql.Tx) {
+			stats = services.GetKeyStats(tx, key)
+		})
+
+		if stats == nil {
+			common.Fail("Key %q does not exist", key)
+			return // To shut up the compiler
+		}
+
+		switch statFlags.output {
+		case "yaml":
+			output, _ := yaml.Marshal(stats)
+			common.Stdout.Print(string(output))
+		case "json":
+			output, _ := json.MarshalIndent(stats, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			formatTime := func(t *time.Time) string {
+				if t == nil {
+					return "-"
+				}
+
+				return color.New(color.FgGreen).Sprint(t.Local().Format(time.DateTime))
+			}
+
+			formatBool := func(value bool) string {
+				if value {
+					return color.New(color.FgYellow).Sprint("Yes")
+				}
+
+				return "No"
+			}
+
+			orDash := func(value string) string {
+				if value == "" {
+					return "-"
+				}
+
+				return value
+			}
+
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+
+			t.AppendRows([]table.Row{
+				{"Key", color.New(color.FgBlue).Sprint(stats.Key)},
+				{"Description", orDash(stats.Description)},
+				{"Tags", orDash(strings.Join(stats.Tags, ", "))},
+				{"Type", orDash(stats.Type)},
+				{"Locked", formatBool(stats.IsLocked)},
+				{"Hidden", formatBool(stats.IsHidden)},
+				{"Link Target", orDash(stats.LinkTarget)},
+				{"Vault", orDash(stats.Vault)},
+				{"Size", fmt.Sprintf("%d bytes", stats.Size)},
+				{"Version", stats.Version},
+				{"Versions", stats.Versions},
+				{"Created At", formatTime(&stats.CreatedAt)},
+				{"Modified At", formatTime(&stats.ModifiedAt)},
+				{"Expires At", formatTime(stats.ExpiresAt)},
+				{"Rotated At", formatTime(stats.RotatedAt)},
+			})
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", statFlags.output)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statCmd)
+
+	statCmd.Flags().StringVarP(&statFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = statCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
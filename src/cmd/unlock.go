@@ -20,6 +20,9 @@ var unlockCmd = &cobra.Command{
 	Short:   "Decrypt a key or keys back to plain text",
 	Long: `Decrypt a key or multiple keys using the provided password, converting them back to plain text.
 
+Keys locked with --recipient are decrypted with your identity, see 'kv keygen'.
+If the agent is running and holds the derived key of a key, the password is not needed for it, see 'kv agent'.
+
 Note: This removes the latest record from history and replaces it with a plain-text one.`,
 	Example: `  # Unlock a single key
   kv unlock api-key --password=mypass
@@ -47,27 +50,8 @@ Note: This removes the latest record from history and replaces it with a plain-t
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
-		password := readPassword(cmd, false)
-		if password == "" {
-			common.Fail("Password cannot be empty")
-		}
-
-		if unlockFlags.all {
-			if len(args) > 0 {
-				common.Fail("Cannot have arguments with --all")
-			}
-
-			services.RunInTransaction(func(tx *sql.Tx) {
-				items := services.ListItems(tx, "", services.MatchExisting)
-				for _, item := range items {
-					err := services.UnlockKey(tx, item.Key, password)
-					if err != nil {
-						common.Fail("Wrong password for key %q", item.Key)
-					}
-				}
-			})
-
-			return
+		if unlockFlags.all && len(args) > 0 {
+			common.Fail("Cannot have arguments with --all")
 		}
 
 		if unlockFlags.prefix {
@@ -77,31 +61,55 @@ Note: This removes the latest record from history and replaces it with a plain-t
 			if len(args) > 1 {
 				common.Fail("Cannot use --prefix with multiple keys")
 			}
+		}
 
-			key := args[0]
-			services.RunInTransaction(func(tx *sql.Tx) {
-				items := services.ListItems(tx, key, services.MatchExisting)
-				for _, item := range items {
-					err := services.UnlockKey(tx, item.Key, password)
-					if err != nil {
-						common.Fail("Wrong password for key %q", item.Key)
-					}
+		if !unlockFlags.all && !unlockFlags.prefix && len(args) == 0 {
+			common.Fail("At least one key must be provided")
+		}
+
+		// Only ask for the password if some key's derived key is not cached in the agent
+		needsPassword := false
+		services.RunInTransaction(func(tx *sql.Tx) {
+			for _, key := range selectKeys(tx, args, unlockFlags.all, unlockFlags.prefix) {
+				item := services.GetItem(tx, key)
+				if item == nil || !item.IsLocked || common.IsRecipientEncrypted(item.Value) {
+					continue
 				}
-			})
 
-			return
-		}
+				if _, found := decryptWithCachedKey(key, item.Value); !found {
+					needsPassword = true
+				}
+			}
+		})
 
-		// Handle multiple keys - fail on first error
-		if len(args) == 0 {
-			common.Fail("At least one key must be provided")
+		var password string
+		if needsPassword {
+			password = readPassword(cmd, false)
+			if password == "" {
+				common.Fail("Password cannot be empty")
+			}
 		}
 
+		// Handle multiple keys - fail on first error
 		services.RunInTransaction(func(tx *sql.Tx) {
-			for _, key := range args {
-				err := services.UnlockKey(tx, key, password)
+			for _, key := range selectKeys(tx, args, unlockFlags.all, unlockFlags.prefix) {
+				err := services.UnlockKey(tx, key, func(value string) (string, error) {
+					if common.IsRecipientEncrypted(value) {
+						return decryptWithIdentity(key, value), nil
+					}
+
+					if plaintext, found := decryptWithCachedKey(key, value); found {
+						return plaintext, nil
+					}
+
+					return common.Decrypt(value, password)
+				})
 				if err != nil {
-					common.Fail("Wrong password")
+					if unlockFlags.all || unlockFlags.prefix {
+						common.Fail("Wrong password for key %q", key)
+					} else {
+						common.Fail("Wrong password")
+					}
 				}
 			}
 		})
@@ -111,8 +119,7 @@ Note: This removes the latest record from history and replaces it with a plain-t
 func init() {
 	rootCmd.AddCommand(unlockCmd)
 
-	unlockCmd.Flags().StringP("password", "p", "", "Encryption password")
-	unlockCmd.Flags().Lookup("password").NoOptDefVal = passwordPromptSentinel
+	addPasswordFlags(unlockCmd, "Encryption password")
 
 	unlockCmd.Flags().BoolVar(&unlockFlags.all, "all", false, "Unlock all keys")
 	unlockCmd.Flags().BoolVar(&unlockFlags.prefix, "prefix", false, "Unlock all keys with given prefix")
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// vaultCreateCmd represents the vault create command
+var vaultCreateCmd = &cobra.Command{
+	Use:   "create <prefix>",
+	Short: "Create a vault for all keys under a prefix",
+	Long: `Create a vault for all keys under a prefix, protected by a single password.
+
+Existing keys under the prefix are encrypted with the vault key, and any key set under the prefix later is encrypted as well.
+Keys under the prefix that are already locked with their own password must be unlocked first.
+
+Note: Like 'kv lock', this only replaces the latest records. Older plain-text values remain in history, consider using 'kv history prune' to remove them.`,
+	Example: `  # Create a vault, enter password interactively
+  kv vault create secrets.
+
+  # Create a vault with an inline password
+  kv vault create secrets. --password=mypass`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeKeyArg(toComplete, services.MatchExisting)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := args[0]
+
+		password := readPassword(cmd, true)
+		if password == "" {
+			common.Fail("Password cannot be empty")
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			services.CreateVault(tx, prefix, password)
+		})
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultCreateCmd)
+
+	addPasswordFlags(vaultCreateCmd, "Vault password")
+}
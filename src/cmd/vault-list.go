@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var vaultListFlags = struct{ output string }{}
+
+// vaultListCmd represents the vault list command
+var vaultListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List vaults and whether they are unlocked",
+	Example: `  # List vaults
+  kv vault list
+
+  # List vaults as JSON
+  kv vault list --output json`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var vaults []services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			vaults = services.ListVaults(tx)
+		})
+
+		if len(vaults) == 0 {
+			common.Stderr.Println("No vaults. Use `kv vault create` to add one.")
+			return
+		}
+
+		type VaultStatus struct {
+			services.Vault `json:",inline" yaml:",inline"`
+			IsUnlocked     bool `json:"isUnlocked" yaml:"is-unlocked"`
+		}
+
+		statuses := make([]VaultStatus, 0, len(vaults))
+		for _, vault := range vaults {
+			key, found := agent.Get(vaultAgentEntry(vault))
+			statuses = append(statuses, VaultStatus{Vault: vault, IsUnlocked: found && vault.Verify(key)})
+		}
+
+		switch vaultListFlags.output {
+		case "yaml":
+			output, _ := yaml.Marshal(statuses)
+			common.Stdout.Println(string(output))
+		case "json":
+			output, _ := json.MarshalIndent(statuses, "", "  ")
+			common.Stdout.Println(string(output))
+		case "table":
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader([]any{"Prefix", "Created At", "Status"})
+
+			for _, status := range statuses {
+				state := color.New(color.FgRed).Sprint("Locked")
+				if status.IsUnlocked {
+					state = color.New(color.FgYellow).Sprint("Unlocked")
+				}
+
+				t.AppendRow([]any{
+					color.New(color.FgBlue).Sprint(status.Prefix),
+					color.New(color.FgGreen).Sprint(status.CreatedAt.Local().Format(time.DateTime)),
+					state,
+				})
+			}
+
+			t.SetStyle(table.StyleLight)
+			t.Render()
+		default:
+			common.Fail("Unsupported format %q", vaultListFlags.output)
+		}
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultListCmd)
+
+	vaultListCmd.Flags().StringVarP(&vaultListFlags.output, "output", "o", "table", "Print format, options: json, yaml, table")
+	_ = vaultListCmd.RegisterFlagCompletionFunc(
+		"output",
+		cobra.FixedCompletions([]string{"json", "yaml", "table"}, cobra.ShellCompDirectiveDefault),
+	)
+}
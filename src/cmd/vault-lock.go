@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// vaultLockCmd represents the vault lock command
+var vaultLockCmd = &cobra.Command{
+	Use:   "lock [prefix...]",
+	Short: "Lock vaults immediately",
+	Long:  `Drop the cached key of the given vaults, or of all vaults if none is given.`,
+	Example: `  # Lock a vault
+  kv vault lock secrets.
+
+  # Lock all vaults
+  kv vault lock`,
+	Args: cobra.ArbitraryArgs,
+
+	ValidArgsFunction: completeVaultArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		var vaults []services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			if len(args) == 0 {
+				vaults = services.ListVaults(tx)
+				return
+			}
+
+			for _, prefix := range args {
+				vault := services.GetVault(tx, prefix)
+				if vault == nil {
+					common.Fail("Vault %q does not exist", prefix)
+					return // To shut up the compiler
+				}
+
+				vaults = append(vaults, *vault)
+			}
+		})
+
+		for _, vault := range vaults {
+			err := agent.Delete(vaultAgentEntry(vault))
+			if err != nil {
+				common.Fail("Could not lock vault %q: %v", vault.Prefix, err)
+			}
+		}
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultLockCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"database/sql"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// vaultRemoveCmd represents the vault remove command
+var vaultRemoveCmd = &cobra.Command{
+	Use:     "remove <prefix>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a vault, decrypting all keys under it",
+	Long: `Remove a vault, decrypting all keys under it back to plain text.
+
+Note: This removes the latest records from history and replaces them with plain-text ones.`,
+	Example: `  # Remove a vault, enter password interactively
+  kv vault remove secrets.`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: completeVaultArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := args[0]
+
+		var vault *services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			vault = services.GetVault(tx, prefix)
+		})
+
+		if vault == nil {
+			common.Fail("Vault %q does not exist", prefix)
+			return // To shut up the compiler
+		}
+
+		key, err := vault.DeriveKey(readPassword(cmd, false))
+		if err != nil {
+			common.Fail("Wrong password")
+		}
+
+		services.RunInTransaction(func(tx *sql.Tx) {
+			services.DeleteVault(tx, *vault, key)
+		})
+
+		_ = agent.Delete(vaultAgentEntry(*vault))
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultRemoveCmd)
+
+	addPasswordFlags(vaultRemoveCmd, "Vault password")
+}
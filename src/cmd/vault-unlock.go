@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+var vaultUnlockFlags = struct{ duration time.Duration }{}
+
+// vaultUnlockCmd represents the vault unlock command
+var vaultUnlockCmd = &cobra.Command{
+	Use:   "unlock <prefix>",
+	Short: "Unlock a vault for a limited time",
+	Long: `Unlock a vault for a limited time, so that keys under it can be read and written without a password.
+
+The derived vault key is cached in memory by a background agent that only the current user can access.
+The password itself is never cached. Use 'kv vault lock' to drop the key before it expires.`,
+	Example: `  # Unlock a vault for 15 minutes
+  kv vault unlock secrets.
+
+  # Unlock a vault for an hour
+  kv vault unlock secrets. --for 1h
+
+  # Read keys without a password while the vault is unlocked
+  kv get secrets.api-key`,
+	Args: cobra.ExactArgs(1),
+
+	ValidArgsFunction: completeVaultArg,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := args[0]
+
+		if vaultUnlockFlags.duration <= 0 {
+			common.Fail("Duration must be positive, got %v", vaultUnlockFlags.duration)
+		}
+
+		var vault *services.Vault
+		services.RunInTransaction(func(tx *sql.Tx) {
+			vault = services.GetVault(tx, prefix)
+		})
+
+		if vault == nil {
+			common.Fail("Vault %q does not exist", prefix)
+			return // To shut up the compiler
+		}
+
+		key, err := vault.DeriveKey(readPassword(cmd, false))
+		if err != nil {
+			common.Fail("Wrong password")
+		}
+
+		err = agent.EnsureRunning()
+		if err != nil {
+			common.Fail("Could not start agent: %v", err)
+		}
+
+		err = agent.Put(vaultAgentEntry(*vault), key, vaultUnlockFlags.duration)
+		if err != nil {
+			common.Fail("Could not cache vault key: %v", err)
+		}
+	},
+}
+
+func completeVaultArg(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var prefixes []string
+	services.RunInTransaction(func(tx *sql.Tx) {
+		for _, vault := range services.ListVaults(tx) {
+			prefixes = append(prefixes, vault.Prefix)
+		}
+	})
+
+	return []cobra.Completion(prefixes), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultUnlockCmd)
+
+	vaultUnlockCmd.Flags().DurationVar(&vaultUnlockFlags.duration, "for", 15*time.Minute, "How long the vault stays unlocked")
+	addPasswordFlags(vaultUnlockCmd, "Vault password")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/AmrSaber/kv/src/agent"
+	"github.com/AmrSaber/kv/src/common"
+	"github.com/AmrSaber/kv/src/services"
+	"github.com/spf13/cobra"
+)
+
+// vaultCmd represents the vault command
+var vaultCmd = &cobra.Command{
+	Use:   "vault [command]",
+	Short: "Password-protected namespaces with session unlock",
+	Long: `Vaults encrypt every key under a prefix with a single password.
+
+Unlocking a vault caches its key in a background agent for a limited time,
+so keys under the vault can be read and written without a password until the vault is locked again.`,
+	GroupID: "security",
+}
+
+func vaultAgentEntry(vault services.Vault) string {
+	return "vault:" + vault.Prefix
+}
+
+// readVaultKey returns the key of vault from the agent if the vault is unlocked,
+// otherwise derives it from cmd's --password flag
+func readVaultKey(cmd *cobra.Command, vault services.Vault) []byte {
+	return readVaultKeyWith(cmd, vault, func() string { return readPassword(cmd, false) })
+}
+
+// readVaultKeyWith is like readVaultKey, reading the password with given function
+func readVaultKeyWith(cmd *cobra.Command, vault services.Vault, readPassword func() string) []byte {
+	if key, found := agent.Get(vaultAgentEntry(vault)); found && vault.Verify(key) {
+		return key
+	}
+
+	if !passwordAvailable(cmd) {
+		common.Fail("Vault %q is locked, unlock it with 'kv vault unlock %s' or pass the password with --password flag", vault.Prefix, vault.Prefix)
+	}
+
+	key, err := vault.DeriveKey(readPassword())
+	if err != nil {
+		common.Fail("Wrong password")
+	}
+
+	return key
+}
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+}
@@ -0,0 +1,255 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BatchOperation is a single operation of a batch, see ParseBatch
+type BatchOperation struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	To    string `json:"to,omitempty"`
+	TTL   string `json:"ttl,omitempty"`
+
+	// Position of the operation in the input, for error messages
+	Position string `json:"-"`
+}
+
+// ExpiresAt returns when the key of op expires relative to now, nil if it does not expire
+func (op BatchOperation) ExpiresAt(now time.Time) *time.Time {
+	if op.TTL == "" || op.TTL == "never" {
+		return nil
+	}
+
+	// Validated by ParseBatch
+	duration, _ := ParseDuration(op.TTL)
+	expiresAt := now.Add(duration)
+
+	return &expiresAt
+}
+
+// batchArgs lists the arguments following the key of each operation, optional arguments end with "?"
+var batchArgs = map[string][]string{
+	"set":    {"value", "ttl?"},
+	"delete": {},
+	"expire": {"ttl"},
+	"lock":   {},
+	"rename": {"to"},
+	"copy":   {"to"},
+}
+
+// ParseBatch parses a list of operations, either as a JSON array of operation objects, e.g.
+//
+//	[{"op": "set", "key": "a", "value": "1", "ttl": "1h"}, {"op": "rename", "key": "a", "to": "b"}]
+//
+// or with one operation per line, where blank lines and lines starting with # are ignored
+// and arguments are quoted like in a shell, e.g.
+//
+//	set a "some value" 1h
+//	rename a b
+func ParseBatch(input string) ([]BatchOperation, error) {
+	var operations []BatchOperation
+
+	if strings.HasPrefix(strings.TrimSpace(input), "[") {
+		if err := json.Unmarshal([]byte(input), &operations); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		for i := range operations {
+			operations[i].Position = fmt.Sprintf("operation %d", i+1)
+		}
+	} else {
+		for i, line := range strings.Split(input, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			position := fmt.Sprintf("line %d", i+1)
+
+			words, err := splitArgs(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", position, err)
+			}
+
+			operation, err := operationFromWords(words)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", position, err)
+			}
+
+			operation.Position = position
+			operations = append(operations, operation)
+		}
+	}
+
+	for _, operation := range operations {
+		if err := validateOperation(operation); err != nil {
+			return nil, fmt.Errorf("%s: %w", operation.Position, err)
+		}
+	}
+
+	return operations, nil
+}
+
+func operationFromWords(words []string) (BatchOperation, error) {
+	op := words[0]
+	args, found := batchArgs[op]
+	if !found {
+		return BatchOperation{}, fmt.Errorf("unknown operation %q", op)
+	}
+
+	required := 0
+	for _, arg := range args {
+		if !strings.HasSuffix(arg, "?") {
+			required++
+		}
+	}
+
+	if len(words) < 2 {
+		return BatchOperation{}, fmt.Errorf("%s needs a key", op)
+	}
+
+	rest := words[2:]
+	if len(rest) < required || len(rest) > len(args) {
+		return BatchOperation{}, fmt.Errorf("usage: %s <key> %s", op, strings.Join(formatArgs(args), " "))
+	}
+
+	operation := BatchOperation{Op: op, Key: words[1]}
+	for i, value := range rest {
+		switch strings.TrimSuffix(args[i], "?") {
+		case "value":
+			operation.Value = value
+		case "ttl":
+			operation.TTL = value
+		case "to":
+			operation.To = value
+		}
+	}
+
+	return operation, nil
+}
+
+func formatArgs(args []string) []string {
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if name, optional := strings.CutSuffix(arg, "?"); optional {
+			formatted = append(formatted, "["+name+"]")
+		} else {
+			formatted = append(formatted, "<"+name+">")
+		}
+	}
+
+	return formatted
+}
+
+func validateOperation(operation BatchOperation) error {
+	if _, found := batchArgs[operation.Op]; !found {
+		return fmt.Errorf("unknown operation %q", operation.Op)
+	}
+
+	if operation.Key == "" {
+		return fmt.Errorf("%s needs a key", operation.Op)
+	}
+
+	switch operation.Op {
+	case "set":
+		if operation.Value == "" {
+			return errors.New("set needs a value")
+		}
+	case "expire":
+		if operation.TTL == "" {
+			return errors.New("expire needs a ttl, or never")
+		}
+	case "rename", "copy":
+		if operation.To == "" {
+			return fmt.Errorf("%s needs a destination key", operation.Op)
+		}
+	}
+
+	if operation.TTL != "" && operation.TTL != "never" {
+		if _, err := ParseDuration(operation.TTL); err != nil {
+			return fmt.Errorf("invalid ttl %q", operation.TTL)
+		}
+	}
+
+	return nil
+}
+
+// splitArgs splits line into words like a shell: single quotes keep text as-is,
+// and backslashes escape the next character outside quotes and within double quotes, with \n for line breaks
+func splitArgs(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\'':
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+
+			if i == len(runes) {
+				return nil, errors.New("unterminated single quote")
+			}
+
+			inWord = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					word.WriteRune(unescape(runes[i]))
+					continue
+				}
+
+				word.WriteRune(runes[i])
+			}
+
+			if i == len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(unescape(runes[i]))
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func unescape(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	default:
+		return r
+	}
+}
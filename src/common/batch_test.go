@@ -0,0 +1,78 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBatchLines(t *testing.T) {
+	input := `
+# Comments and blank lines are ignored
+set app.port 9090 1h
+set app.motd "hello \"world\"\nbye" 
+set app.path 'C:\temp dir'
+delete app.legacy
+expire app.session never
+lock app.token
+rename app.old app.new
+copy app.new app.backup
+`
+
+	operations, err := ParseBatch(input)
+	if err != nil {
+		t.Fatalf("ParseBatch failed: %v", err)
+	}
+
+	expected := []BatchOperation{
+		{Op: "set", Key: "app.port", Value: "9090", TTL: "1h", Position: "line 3"},
+		{Op: "set", Key: "app.motd", Value: "hello \"world\"\nbye", Position: "line 4"},
+		{Op: "set", Key: "app.path", Value: `C:\temp dir`, Position: "line 5"},
+		{Op: "delete", Key: "app.legacy", Position: "line 6"},
+		{Op: "expire", Key: "app.session", TTL: "never", Position: "line 7"},
+		{Op: "lock", Key: "app.token", Position: "line 8"},
+		{Op: "rename", Key: "app.old", To: "app.new", Position: "line 9"},
+		{Op: "copy", Key: "app.new", To: "app.backup", Position: "line 10"},
+	}
+
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("ParseBatch() = %+v, expected %+v", operations, expected)
+	}
+}
+
+func TestParseBatchJSON(t *testing.T) {
+	operations, err := ParseBatch(`[{"op": "set", "key": "a", "value": "1", "ttl": "2d"}, {"op": "rename", "key": "a", "to": "b"}]`)
+	if err != nil {
+		t.Fatalf("ParseBatch failed: %v", err)
+	}
+
+	expected := []BatchOperation{
+		{Op: "set", Key: "a", Value: "1", TTL: "2d", Position: "operation 1"},
+		{Op: "rename", Key: "a", To: "b", Position: "operation 2"},
+	}
+
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("ParseBatch() = %+v, expected %+v", operations, expected)
+	}
+}
+
+func TestParseBatchErrors(t *testing.T) {
+	invalid := []string{
+		"unknown key",
+		"set key",
+		"set key value 1h extra",
+		"delete",
+		"expire key soon",
+		"rename key",
+		`set key "unterminated`,
+		"set key 'unterminated",
+		`[{"op": "set", "key": "a"}]`,
+		`[{"op": "drop", "key": "a"}]`,
+		`[{"op": "set"`,
+	}
+
+	for _, input := range invalid {
+		if _, err := ParseBatch(input); err == nil {
+			t.Errorf("ParseBatch(%q) should fail", input)
+		}
+	}
+}
@@ -8,8 +8,12 @@ import (
 )
 
 type Config struct {
-	PruneHistoryAfterDays int `json:"pruneHistoryAfterDays" yaml:"prune-history-after-days,omitempty"`
-	HistoryLength         int `json:"historyLength" yaml:"history-length,omitempty"`
+	PruneHistoryAfterDays int    `json:"pruneHistoryAfterDays" yaml:"prune-history-after-days,omitempty"`
+	HistoryLength         int    `json:"historyLength" yaml:"history-length,omitempty"`
+	PasswordCommand       string `json:"passwordCommand,omitempty" yaml:"password-command,omitempty"`
+	KDF                   string `json:"kdf" yaml:"kdf,omitempty"`
+	Identity              string `json:"identity,omitempty" yaml:"identity,omitempty"`
+	Clipboard             string `json:"clipboard,omitempty" yaml:"clipboard,omitempty"`
 }
 
 func (c Config) String() string {
@@ -23,6 +27,7 @@ func ReadConfig() Config {
 	config := Config{
 		PruneHistoryAfterDays: 30,
 		HistoryLength:         15,
+		KDF:                   "argon2id",
 	}
 
 	configPath := getConfigPath()
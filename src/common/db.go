@@ -22,6 +22,21 @@ func CloseDB() {
 		_ = db.Close()
 		db = nil
 	}
+
+	encryptedDB.wrappedKey, encryptedDB.key = "", nil
+	unlockDBFile()
+}
+
+// ReleaseDB closes an encrypted database between transactions, so other processes can use it meanwhile;
+// it's reopened by the next GetDB without asking for its master key again. Plain databases stay open.
+func ReleaseDB() {
+	if db == nil || encryptedDB.key == nil {
+		return
+	}
+
+	key := encryptedDB.key
+	CloseDB()
+	encryptedDB.cachedKey = key
 }
 
 func ClearDB() {
@@ -44,6 +59,41 @@ func GetDB() (*sql.DB, error) {
 }
 
 func openDB() (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+
+	if IsDBEncrypted() {
+		db, err = openEncryptedDB()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		db, err = openPlainDB()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Migrations transaction
+	tx, err := BeginTransaction(db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	// Run new migration system
+	runMigrations(tx)
+
+	err = CommitTransaction(db, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func openPlainDB() (*sql.DB, error) {
 	dbPath := GetDBPath()
 	err := os.MkdirAll(path.Dir(dbPath), os.ModeDir|os.ModePerm)
 	FailOn(err)
@@ -63,23 +113,46 @@ func openDB() (*sql.DB, error) {
 		}
 	}
 
-	// Migrations transaction
-	tx, err := BeginTransaction(db)
+	return db, nil
+}
+
+// CommitTransaction commits tx, and writes the database back to disk if it's encrypted
+func CommitTransaction(db *sql.DB, tx *sql.Tx) error {
+	err := tx.Commit()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer func() { _ = tx.Rollback() }()
+	if encryptedDB.key != nil {
+		return persistEncryptedDB(db)
+	}
 
-	// Run new migration system
-	runMigrations(tx)
+	return nil
+}
 
-	err = tx.Commit()
+// CompactDB rebuilds the database file and empties the WAL file, so that deleted data is no longer present on disk
+func CompactDB() error {
+	db, err := GetDB()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return db, nil
+	// Merge full-text index segments, so entries of deleted values are dropped from them
+	for _, statement := range []string{
+		"INSERT INTO values_fts (values_fts) VALUES ('optimize')",
+		"INSERT INTO descriptions_fts (descriptions_fts) VALUES ('optimize')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return err
+	}
+
+	_, err = db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
 }
 
 func GetDBPath() string {
@@ -120,10 +193,20 @@ func BackupDB(writer io.Writer) error {
 		return err
 	}
 
+	// Encrypted databases are backed up in their encrypted form
+	backupPath := GetDBPath()
+	if encryptedDB.key != nil {
+		if err := persistEncryptedDB(db); err != nil {
+			return err
+		}
+
+		backupPath = GetEncryptedDBPath()
+	}
+
 	// Close database connection
 	CloseDB()
 
-	dbFile, err := os.Open(GetDBPath())
+	dbFile, err := os.Open(backupPath)
 	if err != nil {
 		return err
 	}
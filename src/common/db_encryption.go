@@ -0,0 +1,574 @@
+package common
+
+import (
+	"bufio"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// An encrypted database is kept in memory while in use, and stored at rest as:
+//
+//	KVDB1
+//	<master key, encrypted with the database password>
+//	<SQL dump of the database, encrypted with the master key>
+//
+// The master key never changes, so it can be cached for the session and the password can change cheaply.
+const encryptedDBHeader = "KVDB1"
+
+const masterKeySize = 32
+
+// ErrDBLocked is returned when the database is encrypted and no master key is available
+var ErrDBLocked = errors.New("database is encrypted, unlock it with 'kv db unlock'")
+
+// MasterKeyProvider returns the master key of the encrypted database whose master key is wrapped in wrappedKey.
+// Keys it returns are checked with verify. It's set by the caller, e.g. to read the key from a cache or ask for a password.
+var MasterKeyProvider = func(wrappedKey string, verify func(key []byte) bool) ([]byte, error) {
+	return nil, ErrDBLocked
+}
+
+// State of the currently open encrypted database
+var encryptedDB struct {
+	wrappedKey string
+	key        []byte
+	changes    int64
+	lock       *os.File
+
+	// cachedKey is the master key of a released database, kept to reopen it without asking for it again
+	cachedKey []byte
+}
+
+// dbLockTimeout is how long to wait for other processes to release the encrypted database
+const dbLockTimeout = 10 * time.Second
+
+const dbLockPollInterval = 50 * time.Millisecond
+
+// GetEncryptedDBPath returns the path of the encrypted database file, which replaces the database file when encryption is on
+func GetEncryptedDBPath() string {
+	return GetDBPath() + ".enc"
+}
+
+// IsDBEncrypted reports whether the database is stored encrypted
+func IsDBEncrypted() bool {
+	_, err := os.Stat(GetEncryptedDBPath())
+	return err == nil
+}
+
+// IsEncryptedDBFile reports whether the file at path is an encrypted database, e.g. a backup of one
+func IsEncryptedDBFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = file.Close() }()
+
+	header := make([]byte, len(encryptedDBHeader)+1)
+	_, err = io.ReadFull(file, header)
+
+	return err == nil && string(header) == encryptedDBHeader+"\n"
+}
+
+// UnwrapMasterKey decrypts the master key of an encrypted database with its password
+func UnwrapMasterKey(wrappedKey string, password string) ([]byte, error) {
+	encodedKey, err := Decrypt(wrappedKey, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(encodedKey)
+}
+
+// ReadDBWrappedKey returns the encrypted master key of the encrypted database at path
+func ReadDBWrappedKey(path string) (string, error) {
+	wrappedKey, _, err := readEncryptedDBFile(path)
+	return wrappedKey, err
+}
+
+// VerifyDBMasterKey reports whether key decrypts the encrypted database at path
+func VerifyDBMasterKey(path string, key []byte) bool {
+	_, content, err := readEncryptedDBFile(path)
+	if err != nil {
+		return false
+	}
+
+	_, err = DecryptWithKey(content, key)
+	return err == nil
+}
+
+// ValidateEncryptedDBFile checks that the encrypted database at path can be decrypted and holds a valid database
+func ValidateEncryptedDBFile(path string) error {
+	dump, _, _, err := loadEncryptedDBFileWithKey(path)
+	if err != nil {
+		return err
+	}
+
+	testDB, err := openMemoryDB(dump)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = testDB.Close() }()
+
+	return testDB.Ping()
+}
+
+// EncryptDB converts the plain database into an encrypted one protected by password, returns the master key
+func EncryptDB(password string) ([]byte, error) {
+	if IsDBEncrypted() {
+		return nil, errors.New("database is already encrypted")
+	}
+
+	key := make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := Encrypt(base64.StdEncoding.EncodeToString(key), password)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, EncryptDBWithKey(wrappedKey, key)
+}
+
+// EncryptDBWithKey converts the plain database into an encrypted one using an existing master key
+func EncryptDBWithKey(wrappedKey string, key []byte) error {
+	if err := lockDBFile(); err != nil {
+		return err
+	}
+
+	defer unlockDBFile()
+
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	dump, err := dumpDB(db)
+	if err != nil {
+		return err
+	}
+
+	if err := writeEncryptedDBFile(wrappedKey, key, dump); err != nil {
+		return err
+	}
+
+	CloseDB()
+	removePlainDBFiles()
+
+	return nil
+}
+
+// DecryptDB converts the encrypted database back into a plain one
+func DecryptDB() error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	dump, err := dumpDB(db)
+	if err != nil {
+		return err
+	}
+
+	dbPath := GetDBPath()
+	tempPath := dbPath + ".tmp"
+	_ = os.Remove(tempPath)
+
+	plainDB, err := sql.Open("sqlite", tempPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = plainDB.Exec(dump)
+	_ = plainDB.Close()
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, dbPath); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Remove(GetEncryptedDBPath()); err != nil {
+		return err
+	}
+
+	CloseDB()
+	return nil
+}
+
+// GetDBMasterKey returns the master key of the open encrypted database along with its encrypted form
+func GetDBMasterKey() (string, []byte) {
+	return encryptedDB.wrappedKey, encryptedDB.key
+}
+
+func openEncryptedDB() (*sql.DB, error) {
+	encryptedPath := GetEncryptedDBPath()
+
+	// The master key is found before locking, so other processes are not blocked while its password is asked for
+	_, _, key, err := loadEncryptedDBFileWithKey(encryptedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockDBFile(); err != nil {
+		return nil, err
+	}
+
+	// Read again under the lock, as another process may have changed the database meanwhile
+	wrappedKey, content, err := readEncryptedDBFile(encryptedPath)
+	if err != nil {
+		unlockDBFile()
+		return nil, err
+	}
+
+	dump, err := DecryptWithKey(content, key)
+	if err != nil {
+		unlockDBFile()
+		return nil, errors.New("could not decrypt database")
+	}
+
+	db, err := openMemoryDB(dump)
+	if err != nil {
+		unlockDBFile()
+		return nil, err
+	}
+
+	encryptedDB.wrappedKey = wrappedKey
+	encryptedDB.key = key
+	encryptedDB.changes = 0
+
+	return db, nil
+}
+
+// openMemoryDB opens an in-memory database built from dump
+func openMemoryDB(dump string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", ":memory:?_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	// The database lives in the only connection, it must never be closed
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	if _, err := db.Exec(dump); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// persistEncryptedDB writes the in-memory database back to disk if it changed since it was last written
+func persistEncryptedDB(db *sql.DB) error {
+	var changes int64
+	if err := db.QueryRow("SELECT total_changes()").Scan(&changes); err != nil {
+		return err
+	}
+
+	if changes == encryptedDB.changes {
+		return nil
+	}
+
+	dump, err := dumpDB(db)
+	if err != nil {
+		return err
+	}
+
+	if err := writeEncryptedDBFile(encryptedDB.wrappedKey, encryptedDB.key, dump); err != nil {
+		return err
+	}
+
+	encryptedDB.changes = changes
+	return nil
+}
+
+// dumpDB returns the SQL statements that rebuild db: tables with their rows, then indexes, triggers and views.
+// Full-text indexes are dumped without their contents, and rebuilt once everything else is loaded.
+func dumpDB(db *sql.DB) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	// tableKind is virtual for full-text indexes and shadow for the tables they store their contents in
+	type schemaEntry struct{ kind, name, sql, tableKind string }
+
+	rows, err := tx.Query(`
+		SELECT master.type, master.name, master.sql, coalesce(tables.type, '')
+		FROM sqlite_master AS master
+		LEFT JOIN pragma_table_list AS tables ON tables.schema = 'main' AND tables.name = master.name
+		WHERE master.sql IS NOT NULL
+		ORDER BY master.rowid
+	`)
+	if err != nil {
+		return "", err
+	}
+
+	var entries []schemaEntry
+	for rows.Next() {
+		var entry schemaEntry
+		if err := rows.Scan(&entry.kind, &entry.name, &entry.sql, &entry.tableKind); err != nil {
+			_ = rows.Close()
+			return "", err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	_ = rows.Close()
+
+	var dump strings.Builder
+	dump.WriteString("BEGIN;\n")
+
+	for _, entry := range entries {
+		if entry.kind != "table" || strings.HasPrefix(entry.name, "sqlite_") || entry.tableKind == "shadow" {
+			continue
+		}
+
+		dump.WriteString(entry.sql + ";\n")
+
+		if entry.tableKind == "virtual" {
+			continue
+		}
+
+		if err := dumpTableRows(tx, entry.name, &dump); err != nil {
+			return "", err
+		}
+	}
+
+	// Created automatically along with AUTOINCREMENT tables
+	if err := dumpTableRows(tx, "sqlite_sequence", &dump); err != nil && !strings.Contains(err.Error(), "no such table") {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if entry.kind != "table" {
+			dump.WriteString(entry.sql + ";\n")
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.tableKind == "virtual" {
+			fmt.Fprintf(&dump, "INSERT INTO %s (%s) VALUES ('rebuild');\n", quoteIdentifier(entry.name), quoteIdentifier(entry.name))
+		}
+	}
+
+	dump.WriteString("COMMIT;\n")
+
+	return dump.String(), nil
+}
+
+// dumpTableRows writes an INSERT statement for each row of table, letting SQLite quote the values
+func dumpTableRows(tx *sql.Tx, table string, dump *strings.Builder) error {
+	columnRows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+
+	var quotedColumns []string
+	for columnRows.Next() {
+		var column string
+		if err := columnRows.Scan(&column); err != nil {
+			_ = columnRows.Close()
+			return err
+		}
+
+		quotedColumns = append(quotedColumns, fmt.Sprintf("quote(%s)", quoteIdentifier(column)))
+	}
+
+	_ = columnRows.Close()
+
+	if len(quotedColumns) == 0 {
+		return fmt.Errorf("no such table: %s", table)
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(
+		"SELECT %s FROM %s",
+		strings.Join(quotedColumns, " || ',' || "),
+		quoteIdentifier(table),
+	))
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var values string
+		if err := rows.Scan(&values); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(dump, "INSERT INTO %s VALUES(%s);\n", quoteIdentifier(table), values)
+	}
+
+	return rows.Err()
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// writeEncryptedDBFile atomically replaces the encrypted database file
+func writeEncryptedDBFile(wrappedKey string, key []byte, dump string) error {
+	content, err := EncryptWithKey(dump, key)
+	if err != nil {
+		return err
+	}
+
+	encryptedPath := GetEncryptedDBPath()
+	if err := os.MkdirAll(path.Dir(encryptedPath), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	tempPath := encryptedPath + ".tmp"
+	fileContent := fmt.Sprintf("%s\n%s\n%s\n", encryptedDBHeader, wrappedKey, content)
+	if err := os.WriteFile(tempPath, []byte(fileContent), 0o600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempPath, encryptedPath); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	return nil
+}
+
+func readEncryptedDBFile(path string) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	reader := bufio.NewReader(file)
+
+	var lines []string
+	for range 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return "", "", errors.New("invalid encrypted database file")
+		}
+
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	if lines[0] != encryptedDBHeader {
+		return "", "", errors.New("invalid encrypted database file")
+	}
+
+	return lines[1], lines[2], nil
+}
+
+// loadEncryptedDBFileWithKey decrypts the encrypted database at path with the key of the last released database,
+// or failing that with the key from MasterKeyProvider
+func loadEncryptedDBFileWithKey(path string) (string, string, []byte, error) {
+	wrappedKey, content, err := readEncryptedDBFile(path)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if encryptedDB.cachedKey != nil {
+		if dump, err := DecryptWithKey(content, encryptedDB.cachedKey); err == nil {
+			return dump, wrappedKey, encryptedDB.cachedKey, nil
+		}
+	}
+
+	var dump string
+	verified := false
+	key, err := MasterKeyProvider(wrappedKey, func(key []byte) bool {
+		decrypted, decryptErr := DecryptWithKey(content, key)
+		if decryptErr != nil {
+			return false
+		}
+
+		dump, verified = decrypted, true
+		return true
+	})
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if !verified {
+		return "", "", nil, errors.New("could not decrypt database")
+	}
+
+	return dump, wrappedKey, key, nil
+}
+
+// lockDBFile prevents other processes from using the encrypted database until unlockDBFile is called.
+// It waits up to dbLockTimeout for other processes to release it.
+func lockDBFile() error {
+	if encryptedDB.lock != nil {
+		return nil
+	}
+
+	lockPath := GetDBPath() + ".lock"
+	if err := os.MkdirAll(path.Dir(lockPath), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(dbLockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+
+		if locked {
+			break
+		}
+
+		if !time.Now().Before(deadline) {
+			_ = file.Close()
+			return fmt.Errorf("database is in use by another kv process, gave up waiting after %s", dbLockTimeout)
+		}
+
+		time.Sleep(dbLockPollInterval)
+	}
+
+	encryptedDB.lock = file
+	return nil
+}
+
+func unlockDBFile() {
+	if encryptedDB.lock == nil {
+		return
+	}
+
+	_ = unlockFile(encryptedDB.lock)
+	_ = encryptedDB.lock.Close()
+	encryptedDB.lock = nil
+}
+
+func removePlainDBFiles() {
+	dbPath := GetDBPath()
+	_ = os.Remove(dbPath)
+	_ = os.Remove(dbPath + "-wal")
+	_ = os.Remove(dbPath + "-shm")
+}
@@ -0,0 +1,541 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a JSON or YAML value that can be read and patched at a path such as .server.port or .hosts[0]
+type Document struct {
+	Format string // "json" or "yaml"
+
+	// root is the decoded JSON value, or the *yaml.Node of a YAML document which keeps its comments
+	root     any
+	indented bool
+}
+
+// jsonField is a member of a JSON object, objects are kept as ordered fields so patching does not reorder keys
+type jsonField struct {
+	Key   string
+	Value any
+}
+
+type jsonObject []jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := marshalJSON(field.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := marshalJSON(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON encodes value without escaping HTML characters, so values like URLs are stored as written
+func marshalJSON(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// ParseDocument parses value as a JSON object or array, or failing that as a YAML mapping or sequence
+func ParseDocument(value string) (*Document, error) {
+	if root, err := decodeJSON(value); err == nil {
+		switch root.(type) {
+		case jsonObject, []any:
+			return &Document{Format: "json", root: root, indented: strings.Contains(value, "\n")}, nil
+		}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &root); err == nil && len(root.Content) == 1 {
+		switch root.Content[0].Kind {
+		case yaml.MappingNode, yaml.SequenceNode:
+			return &Document{Format: "yaml", root: root.Content[0]}, nil
+		}
+	}
+
+	return nil, errors.New("value is not a JSON or YAML document")
+}
+
+// decodeJSON decodes value keeping the order of object keys and the exact text of numbers
+func decodeJSON(value string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	decoded, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	return decoded, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := jsonObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			object = append(object, jsonField{Key: key.(string), Value: value})
+		}
+
+		_, err = decoder.Token()
+		return object, err
+	case '[':
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		_, err = decoder.Token()
+		return array, err
+	default:
+		return nil, fmt.Errorf("unexpected %q", delim)
+	}
+}
+
+// pathSegment is either an object key or an array index
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func (s pathSegment) String() string {
+	if s.isIndex {
+		return fmt.Sprintf("[%d]", s.index)
+	}
+
+	return "." + s.key
+}
+
+// parsePath splits a path such as .server.hosts[0].name into segments, "." alone is the whole document
+func parsePath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		return nil, fmt.Errorf("invalid path %q, paths start with '.', e.g. .server.port", path)
+	}
+
+	if path == "." {
+		return nil, nil
+	}
+
+	var segments []pathSegment
+	rest := path
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q, empty key", path)
+			}
+
+			segments = append(segments, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path %q, missing ']'", path)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q, %q is not an array index", path, rest[1:end])
+			}
+
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	return segments, nil
+}
+
+// Get returns the node at path, failing if any part of the path does not exist
+func (d *Document) Get(path string) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := d.root
+	for i, segment := range segments {
+		child, found, err := childNode(node, segment)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", formatSegments(segments[:i]), err)
+		}
+
+		if !found {
+			return nil, fmt.Errorf("path %s does not exist", formatSegments(segments[:i+1]))
+		}
+
+		node = child
+	}
+
+	return node, nil
+}
+
+// Set replaces the node at path with value, given as JSON or YAML text; text that does not parse is set as a string.
+// Missing object keys along the path are created, and an index equal to the length of an array appends to it.
+func (d *Document) Set(path string, value string) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	root, err := d.setNode(d.root, segments, 0, d.parseNode(value))
+	if err != nil {
+		return err
+	}
+
+	d.root = root
+	return nil
+}
+
+// parseNode parses value in the format of the document
+func (d *Document) parseNode(value string) any {
+	if d.Format == "json" {
+		if node, err := decodeJSON(value); err == nil {
+			return node
+		}
+
+		return value
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &node); err == nil && len(node.Content) == 1 && node.Content[0].Tag != "!!null" {
+		return node.Content[0]
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func (d *Document) setNode(node any, segments []pathSegment, depth int, value any) (any, error) {
+	if depth == len(segments) {
+		keepYAMLComments(node, value)
+		return value, nil
+	}
+
+	segment := segments[depth]
+
+	if isNullYAML(node) {
+		node = nil
+	}
+
+	if node == nil && !segment.isIndex {
+		if d.Format == "json" {
+			node = jsonObject{}
+		} else {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+	}
+
+	child, _, err := childNode(node, segment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", formatSegments(segments[:depth]), err)
+	}
+
+	child, err = d.setNode(child, segments, depth+1, value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := node.(type) {
+	case jsonObject:
+		for i, field := range node {
+			if field.Key == segment.key {
+				node[i].Value = child
+				return node, nil
+			}
+		}
+
+		return append(node, jsonField{Key: segment.key, Value: child}), nil
+	case *yaml.Node:
+		node = resolveYAMLAlias(node)
+		if segment.isIndex {
+			if segment.index == len(node.Content) {
+				node.Content = append(node.Content, child.(*yaml.Node))
+				return node, nil
+			}
+
+			if segment.index > len(node.Content) {
+				return nil, fmt.Errorf("path %s is out of range, array has %d item(s)", formatSegments(segments[:depth+1]), len(node.Content))
+			}
+
+			node.Content[segment.index] = child.(*yaml.Node)
+			return node, nil
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment.key {
+				node.Content[i+1] = child.(*yaml.Node)
+				return node, nil
+			}
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment.key}
+		node.Content = append(node.Content, key, child.(*yaml.Node))
+		return node, nil
+	case []any:
+		if segment.index == len(node) {
+			return append(node, child), nil
+		}
+
+		if segment.index > len(node) {
+			return nil, fmt.Errorf("path %s is out of range, array has %d item(s)", formatSegments(segments[:depth+1]), len(node))
+		}
+
+		node[segment.index] = child
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("path %s is not an object or array", formatSegments(segments[:depth]))
+}
+
+// childNode returns the child of node at segment, and whether it was found;
+// it fails if node cannot have children of that kind
+func childNode(node any, segment pathSegment) (any, bool, error) {
+	if yamlNode, ok := node.(*yaml.Node); ok {
+		return childYAMLNode(yamlNode, segment)
+	}
+
+	if segment.isIndex {
+		array, ok := node.([]any)
+		if !ok {
+			return nil, false, errors.New("not an array")
+		}
+
+		if segment.index >= len(array) {
+			return nil, false, nil
+		}
+
+		return array[segment.index], true, nil
+	}
+
+	switch node := node.(type) {
+	case jsonObject:
+		for _, field := range node {
+			if field.Key == segment.key {
+				return field.Value, true, nil
+			}
+		}
+	default:
+		return nil, false, errors.New("not an object")
+	}
+
+	return nil, false, nil
+}
+
+func childYAMLNode(node *yaml.Node, segment pathSegment) (any, bool, error) {
+	node = resolveYAMLAlias(node)
+
+	if segment.isIndex {
+		if node.Kind != yaml.SequenceNode {
+			return nil, false, errors.New("not an array")
+		}
+
+		if segment.index >= len(node.Content) {
+			return nil, false, nil
+		}
+
+		return node.Content[segment.index], true, nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, false, errors.New("not an object")
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == segment.key {
+			return node.Content[i+1], true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return node.Alias
+	}
+
+	return node
+}
+
+func isNullYAML(node any) bool {
+	yamlNode, ok := node.(*yaml.Node)
+	return ok && yamlNode.Kind == yaml.ScalarNode && yamlNode.Tag == "!!null"
+}
+
+// keepYAMLComments moves the comments of a replaced YAML node to its replacement, unless it has its own
+func keepYAMLComments(old any, replacement any) {
+	oldNode, ok := old.(*yaml.Node)
+	if !ok {
+		return
+	}
+
+	newNode, ok := replacement.(*yaml.Node)
+	if !ok || newNode.HeadComment != "" || newNode.LineComment != "" || newNode.FootComment != "" {
+		return
+	}
+
+	newNode.HeadComment = oldNode.HeadComment
+	newNode.LineComment = oldNode.LineComment
+	newNode.FootComment = oldNode.FootComment
+}
+
+func formatSegments(segments []pathSegment) string {
+	if len(segments) == 0 {
+		return "."
+	}
+
+	var builder strings.Builder
+	for _, segment := range segments {
+		builder.WriteString(segment.String())
+	}
+
+	return builder.String()
+}
+
+// String encodes the document back in its format, JSON is indented if the parsed value was
+func (d *Document) String() (string, error) {
+	if d.Format == "json" {
+		encoded, err := marshalJSON(d.root)
+		if err != nil {
+			return "", err
+		}
+
+		if !d.indented {
+			return string(encoded), nil
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, encoded, "", "  "); err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	}
+
+	return encodeYAML(d.root.(*yaml.Node))
+}
+
+// FormatNode encodes node as returned by Get: strings as-is, other scalars as text,
+// and objects or arrays in the format of the document
+func (d *Document) FormatNode(node any) (string, error) {
+	switch node := node.(type) {
+	case string:
+		return node, nil
+	case nil:
+		return "null", nil
+	case *yaml.Node:
+		node = resolveYAMLAlias(node)
+		if node.Kind == yaml.ScalarNode {
+			if node.Tag == "!!null" {
+				return "null", nil
+			}
+
+			return node.Value, nil
+		}
+
+		return encodeYAML(node)
+	case jsonObject, []any:
+		encoded, err := marshalJSON(node)
+		if err != nil {
+			return "", err
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, encoded, "", "  "); err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	default:
+		return fmt.Sprint(node), nil
+	}
+}
+
+// encodeYAML encodes node with its comments, indented by 2 spaces
+func encodeYAML(node *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,136 @@
+package common
+
+import "testing"
+
+func TestDocumentGet(t *testing.T) {
+	doc, err := ParseDocument(`{"server": {"port": 8080, "hosts": ["a", "b"]}, "debug": true}`)
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	cases := map[string]string{
+		".server.port":     "8080",
+		".server.hosts[1]": "b",
+		".debug":           "true",
+		".server.hosts":    "[\n  \"a\",\n  \"b\"\n]",
+	}
+
+	for path, expected := range cases {
+		node, err := doc.Get(path)
+		if err != nil {
+			t.Errorf("Get(%q) failed: %v", path, err)
+			continue
+		}
+
+		formatted, err := doc.FormatNode(node)
+		if err != nil {
+			t.Errorf("FormatNode for %q failed: %v", path, err)
+		}
+
+		if formatted != expected {
+			t.Errorf("Get(%q) = %q, expected %q", path, formatted, expected)
+		}
+	}
+
+	for _, path := range []string{".missing", ".server.hosts[5]", ".debug.value", "server", ".server..port"} {
+		if _, err := doc.Get(path); err == nil {
+			t.Errorf("Get(%q) should fail", path)
+		}
+	}
+}
+
+func TestDocumentSetJSON(t *testing.T) {
+	doc, err := ParseDocument(`{"server":{"port":8080,"url":"http://a?b&c"},"debug":true}`)
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	steps := map[string]string{
+		".server.port":  "9090",
+		".debug":        "false",
+		".name":         "api",
+		".tags":         `["x"]`,
+		".tags[1]":      `"y"`,
+		".limits.burst": "10",
+	}
+
+	for _, path := range []string{".server.port", ".debug", ".name", ".tags", ".tags[1]", ".limits.burst"} {
+		if err := doc.Set(path, steps[path]); err != nil {
+			t.Fatalf("Set(%q) failed: %v", path, err)
+		}
+	}
+
+	output, err := doc.String()
+	if err != nil {
+		t.Fatalf("String failed: %v", err)
+	}
+
+	expected := `{"server":{"port":9090,"url":"http://a?b&c"},"debug":false,"name":"api","tags":["x","y"],"limits":{"burst":10}}`
+	if output != expected {
+		t.Errorf("String() = %s, expected %s", output, expected)
+	}
+
+	if err := doc.Set(".tags[5]", "z"); err == nil {
+		t.Error("Set past the end of an array should fail")
+	}
+
+	if err := doc.Set(".debug.value", "1"); err == nil {
+		t.Error("Set inside a scalar should fail")
+	}
+}
+
+func TestDocumentSetYAML(t *testing.T) {
+	doc, err := ParseDocument("server:\n  port: 8080\n  host: localhost\ndebug: true")
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	if doc.Format != "yaml" {
+		t.Fatalf("Format = %q, expected yaml", doc.Format)
+	}
+
+	if err := doc.Set(".server.port", "9090"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	output, err := doc.String()
+	if err != nil {
+		t.Fatalf("String failed: %v", err)
+	}
+
+	expected := "server:\n  port: 9090\n  host: localhost\ndebug: true"
+	if output != expected {
+		t.Errorf("String() = %q, expected %q", output, expected)
+	}
+}
+
+func TestDocumentSetYAMLKeepsComments(t *testing.T) {
+	doc, err := ParseDocument("# app config\nserver:\n  # public port\n  port: 8080 # http\n  hosts:\n    - a # primary\n")
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	for path, value := range map[string]string{".server.port": "9090", ".server.hosts[1]": "b", ".debug": "true"} {
+		if err := doc.Set(path, value); err != nil {
+			t.Fatalf("Set(%q) failed: %v", path, err)
+		}
+	}
+
+	output, err := doc.String()
+	if err != nil {
+		t.Fatalf("String failed: %v", err)
+	}
+
+	expected := "# app config\nserver:\n  # public port\n  port: 9090 # http\n  hosts:\n    - a # primary\n    - b\ndebug: true"
+	if output != expected {
+		t.Errorf("String() = %q, expected %q", output, expected)
+	}
+}
+
+func TestParseDocumentRejectsScalars(t *testing.T) {
+	for _, value := range []string{"plain text", "8080", `"quoted"`} {
+		if _, err := ParseDocument(value); err == nil {
+			t.Errorf("ParseDocument(%q) should fail", value)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationPartRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)(ns|us|µs|ms|s|m|h|d|w)`)
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// ParseDuration parses a duration like time.ParseDuration, additionally accepting d (day) and w (week) units, e.g. 90d or 1w2d12h
+func ParseDuration(value string) (time.Duration, error) {
+	input := strings.TrimSpace(value)
+
+	sign := time.Duration(1)
+	if strings.HasPrefix(input, "-") {
+		sign = -1
+		input = input[1:]
+	}
+
+	if input == "0" {
+		return 0, nil
+	}
+
+	parts := durationPartRegex.FindAllStringSubmatchIndex(input, -1)
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	end := 0
+	for _, part := range parts {
+		// Parts must cover the whole input with nothing in between
+		if part[0] != end {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		end = part[1]
+
+		amount, err := strconv.ParseFloat(input[part[2]:part[3]], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+
+		total += time.Duration(amount * float64(durationUnits[input[part[4]:part[5]]]))
+	}
+
+	if end != len(input) {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * total, nil
+}
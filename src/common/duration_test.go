@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	valid := map[string]time.Duration{
+		"0":        0,
+		"90d":      90 * 24 * time.Hour,
+		"2w":       14 * 24 * time.Hour,
+		"1d12h":    36 * time.Hour,
+		"1h30m":    90 * time.Minute,
+		"1.5d":     36 * time.Hour,
+		"500ms":    500 * time.Millisecond,
+		"-1d":      -24 * time.Hour,
+		"1w2d3h4m": (9*24+3)*time.Hour + 4*time.Minute,
+	}
+
+	for input, expected := range valid {
+		actual, err := ParseDuration(input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) failed: %v", input, err)
+			continue
+		}
+
+		if actual != expected {
+			t.Errorf("ParseDuration(%q) = %v, expected %v", input, actual, expected)
+		}
+	}
+
+	for _, input := range []string{"", "d", "90", "90x", "1d 2h", "1mo", "abc"} {
+		if _, err := ParseDuration(input); err == nil {
+			t.Errorf("ParseDuration(%q) should fail", input)
+		}
+	}
+}
@@ -1,94 +1,445 @@
 package common
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/pbkdf2"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Encrypted values are base64-encoded envelopes:
+//
+//	magic (3 bytes) | version (1 byte) | kdf (1 byte) | kdf params | salt length (1 byte) | salt | nonce | ciphertext
+//
+// Everything before the nonce is the header, which is authenticated as GCM additional data.
+// Values encrypted before envelopes were introduced have no header: salt (32 bytes) | nonce | ciphertext,
+// with the key derived using PBKDF2-SHA256 at 10,000 iterations. Those can still be decrypted.
+
+// KDF identifies the key derivation function used for a value
+type KDF byte
+
+const (
+	// KDFNone means the value was encrypted with an already derived key, e.g. a vault key
+	KDFNone KDF = iota
+	KDFPBKDF2
+	KDFArgon2id
+)
+
+const (
+	envelopeMagic   = "KVE"
+	envelopeVersion = 1
+
+	keySize        = 32
+	saltSize       = 32
+	legacySaltSize = 32
+
+	legacyPBKDF2Iterations = 10_000
+	pbkdf2Iterations       = 600_000
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+
+	// Upper bounds for parameters read from envelopes, so a tampered value cannot exhaust resources
+	maxPBKDF2Iterations = 10_000_000
+	maxArgon2Time       = 100
+	maxArgon2Memory     = 1024 * 1024 // KiB
 )
 
+var errMalformedCiphertext = errors.New("malformed ciphertext")
+
+// ParseKDF parses a KDF name as used in config
+func ParseKDF(name string) (KDF, error) {
+	switch name {
+	case "argon2id":
+		return KDFArgon2id, nil
+	case "pbkdf2":
+		return KDFPBKDF2, nil
+	default:
+		return 0, fmt.Errorf("unsupported kdf %q, options: argon2id, pbkdf2", name)
+	}
+}
+
+func (kdf KDF) String() string {
+	switch kdf {
+	case KDFNone:
+		return "none"
+	case KDFPBKDF2:
+		return "pbkdf2"
+	case KDFArgon2id:
+		return "argon2id"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(kdf))
+	}
+}
+
+type envelope struct {
+	kdf        KDF
+	iterations uint32 // PBKDF2
+	time       uint32 // Argon2id
+	memory     uint32 // Argon2id, in KiB
+	threads    uint8  // Argon2id
+	salt       []byte
+
+	// header is authenticated as additional data, it's nil for legacy values
+	header  []byte
+	payload []byte
+}
+
+// newEnvelope returns an envelope for kdf with current default parameters and a fresh salt
+func newEnvelope(kdf KDF) (envelope, error) {
+	env := envelope{kdf: kdf}
+
+	switch kdf {
+	case KDFNone:
+	case KDFPBKDF2:
+		env.iterations = pbkdf2Iterations
+	case KDFArgon2id:
+		env.time, env.memory, env.threads = argon2Time, argon2Memory, argon2Threads
+	default:
+		return env, fmt.Errorf("unsupported kdf %v", kdf)
+	}
+
+	if kdf != KDFNone {
+		env.salt = make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, env.salt); err != nil {
+			return env, err
+		}
+	}
+
+	env.header = env.marshalHeader()
+	return env, nil
+}
+
+func (env envelope) marshalHeader() []byte {
+	var header bytes.Buffer
+	header.WriteString(envelopeMagic)
+	header.WriteByte(envelopeVersion)
+	header.WriteByte(byte(env.kdf))
+
+	switch env.kdf {
+	case KDFPBKDF2:
+		_ = binary.Write(&header, binary.BigEndian, env.iterations)
+	case KDFArgon2id:
+		_ = binary.Write(&header, binary.BigEndian, env.time)
+		_ = binary.Write(&header, binary.BigEndian, env.memory)
+		header.WriteByte(env.threads)
+	}
+
+	if env.kdf != KDFNone {
+		header.WriteByte(byte(len(env.salt)))
+		header.Write(env.salt)
+	}
+
+	return header.Bytes()
+}
+
+// parseEnvelope parses data as a versioned envelope, reporting false if it's not one
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+
+	reader := bytes.NewReader(data)
+	prefix := make([]byte, len(envelopeMagic)+2)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return env, false
+	}
+
+	if string(prefix[:len(envelopeMagic)]) != envelopeMagic || prefix[len(envelopeMagic)] != envelopeVersion {
+		return env, false
+	}
+
+	env.kdf = KDF(prefix[len(envelopeMagic)+1])
+
+	switch env.kdf {
+	case KDFNone:
+	case KDFPBKDF2:
+		if binary.Read(reader, binary.BigEndian, &env.iterations) != nil {
+			return env, false
+		}
+
+		if env.iterations == 0 || env.iterations > maxPBKDF2Iterations {
+			return env, false
+		}
+	case KDFArgon2id:
+		if binary.Read(reader, binary.BigEndian, &env.time) != nil ||
+			binary.Read(reader, binary.BigEndian, &env.memory) != nil ||
+			binary.Read(reader, binary.BigEndian, &env.threads) != nil {
+			return env, false
+		}
+
+		if env.time == 0 || env.time > maxArgon2Time || env.memory == 0 || env.memory > maxArgon2Memory || env.threads == 0 {
+			return env, false
+		}
+	default:
+		return env, false
+	}
+
+	if env.kdf != KDFNone {
+		saltLength, err := reader.ReadByte()
+		if err != nil || saltLength == 0 {
+			return env, false
+		}
+
+		env.salt = make([]byte, saltLength)
+		if _, err := io.ReadFull(reader, env.salt); err != nil {
+			return env, false
+		}
+	}
+
+	headerLength := len(data) - reader.Len()
+	env.header = data[:headerLength]
+	env.payload = data[headerLength:]
+
+	return env, true
+}
+
+// envelopeCandidates returns the possible interpretations of data, most likely first.
+// Legacy values are random bytes, so they could start with the envelope magic by pure chance.
+func envelopeCandidates(data []byte, legacyKDF KDF) []envelope {
+	var candidates []envelope
+	if env, ok := parseEnvelope(data); ok {
+		candidates = append(candidates, env)
+	}
+
+	legacy := envelope{kdf: legacyKDF, payload: data}
+	if legacyKDF == KDFPBKDF2 {
+		if len(data) < legacySaltSize {
+			return candidates
+		}
+
+		legacy.iterations = legacyPBKDF2Iterations
+		legacy.salt, legacy.payload = data[:legacySaltSize], data[legacySaltSize:]
+	}
+
+	return append(candidates, legacy)
+}
+
+func (env envelope) deriveKey(password string) ([]byte, error) {
+	switch env.kdf {
+	case KDFPBKDF2:
+		return pbkdf2.Key(sha256.New, password, env.salt, int(env.iterations), keySize)
+	case KDFArgon2id:
+		return argon2.IDKey([]byte(password), env.salt, env.time, env.memory, env.threads, keySize), nil
+	default:
+		return nil, fmt.Errorf("kdf %v cannot derive keys", env.kdf)
+	}
+}
+
+// isCurrent reports whether the envelope uses the current format and default parameters of kdf
+func (env envelope) isCurrent(kdf KDF) bool {
+	if env.header == nil || env.kdf != kdf {
+		return false
+	}
+
+	current, err := newEnvelope(kdf)
+	if err != nil {
+		return false
+	}
+
+	return env.iterations == current.iterations && env.time == current.time &&
+		env.memory == current.memory && env.threads == current.threads
+}
+
+// Encrypt encrypts plaintext with a key derived from password, using the KDF from config
 func Encrypt(plaintext string, password string) (string, error) {
-	// Generate a random salt
-	salt := make([]byte, 32)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+	kdf, err := ParseKDF(ReadConfig().KDF)
+	if err != nil {
 		return "", err
 	}
 
-	// Derive a 32-byte key from the password using PBKDF2
-	key, err := pbkdf2.Key(sha256.New, password, salt, 10_000, 32)
+	return EncryptWithKDF(plaintext, password, kdf)
+}
+
+// EncryptWithKDF encrypts plaintext with a key derived from password using kdf
+func EncryptWithKDF(plaintext string, password string, kdf KDF) (string, error) {
+	if kdf == KDFNone {
+		return "", errors.New("a kdf is required to encrypt with a password")
+	}
+
+	env, err := newEnvelope(kdf)
 	if err != nil {
 		return "", err
 	}
 
-	// Create AES cipher block
-	block, err := aes.NewCipher(key)
+	key, err := env.deriveKey(password)
 	if err != nil {
 		return "", err
 	}
 
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	return env.seal(plaintext, key)
+}
+
+func Decrypt(encryptedB64 string, password string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encryptedB64)
 	if err != nil {
 		return "", err
 	}
 
-	// Create nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	err = errMalformedCiphertext
+	for _, env := range envelopeCandidates(data, KDFPBKDF2) {
+		var key []byte
+		key, err = env.deriveKey(password)
+		if err != nil {
+			continue
+		}
+
+		var plaintext string
+		plaintext, err = env.open(key)
+		if err == nil {
+			return plaintext, nil
+		}
 	}
 
-	// Encrypt
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return "", err
+}
 
-	// Prepend salt to the result
-	result := append(salt, ciphertext...)
+// DeriveKeyFor derives the key that decrypts the output of Encrypt from password, see DecryptWithDerivedKey
+func DeriveKeyFor(encryptedB64 string, password string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encryptedB64)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use Base64 instead of hex
-	return base64.StdEncoding.EncodeToString(result), nil
+	err = errMalformedCiphertext
+	for _, env := range envelopeCandidates(data, KDFPBKDF2) {
+		var key []byte
+		key, err = env.deriveKey(password)
+		if err != nil {
+			continue
+		}
+
+		if _, err = env.open(key); err == nil {
+			return key, nil
+		}
+	}
+
+	return nil, err
 }
 
-func Decrypt(encryptedB64 string, password string) (string, error) {
-	// Decode from Base64
+// DecryptWithDerivedKey decrypts the output of Encrypt using a key from DeriveKeyFor, skipping key derivation
+func DecryptWithDerivedKey(encryptedB64 string, key []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encryptedB64)
+	if err != nil {
+		return "", err
+	}
+
+	err = errMalformedCiphertext
+	for _, env := range envelopeCandidates(data, KDFPBKDF2) {
+		var plaintext string
+		if plaintext, err = env.open(key); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return "", err
+}
+
+// IsEncryptionOutdated reports whether the output of Encrypt uses an older format or
+// different KDF parameters than what Encrypt currently produces
+func IsEncryptionOutdated(encryptedB64 string) (bool, error) {
+	kdf, err := ParseKDF(ReadConfig().KDF)
+	if err != nil {
+		return false, err
+	}
+
 	data, err := base64.StdEncoding.DecodeString(encryptedB64)
+	if err != nil {
+		return false, err
+	}
+
+	env, ok := parseEnvelope(data)
+	return !ok || !env.isCurrent(kdf), nil
+}
+
+// EncryptWithKey encrypts plaintext with an already derived key, e.g. a vault key
+func EncryptWithKey(plaintext string, key []byte) (string, error) {
+	env, err := newEnvelope(KDFNone)
 	if err != nil {
 		return "", err
 	}
 
-	// Extract salt (first 32 bytes)
-	salt := data[:32]
-	ciphertext := data[32:]
+	return env.seal(plaintext, key)
+}
 
-	// Derive the same key using the salt
-	key, err := pbkdf2.Key(sha256.New, password, salt, 10_000, 32)
+// DecryptWithKey decrypts the output of EncryptWithKey using the same key
+func DecryptWithKey(encryptedB64 string, key []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encryptedB64)
 	if err != nil {
 		return "", err
 	}
 
-	// Create AES cipher block
-	block, err := aes.NewCipher(key)
+	err = errMalformedCiphertext
+	for _, env := range envelopeCandidates(data, KDFNone) {
+		var plaintext string
+		if plaintext, err = env.open(key); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return "", err
+}
+
+// seal encrypts plaintext with key and returns the base64-encoded envelope
+func (env envelope) seal(plaintext string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
 
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	// Create nonce
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// Encrypt, authenticating the header
+	result := append([]byte{}, env.header...)
+	result = append(result, nonce...)
+	result = gcm.Seal(result, nonce, []byte(plaintext), env.header)
+
+	return base64.StdEncoding.EncodeToString(result), nil
+}
+
+// open decrypts the envelope's payload with key
+func (env envelope) open(key []byte) (string, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
 
 	// Extract nonce and encrypted data
 	nonceSize := gcm.NonceSize()
-	nonce, encrypted := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	if len(env.payload) < nonceSize {
+		return "", errMalformedCiphertext
+	}
+
+	nonce, encrypted := env.payload[:nonceSize], env.payload[nonceSize:]
 
 	// Decrypt
-	plaintext, err := gcm.Open(nil, nonce, encrypted, nil)
+	plaintext, err := gcm.Open(nil, nonce, encrypted, env.header)
 	if err != nil {
 		return "", err
 	}
 
 	return string(plaintext), nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	// Create AES cipher block
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create GCM mode
+	return cipher.NewGCM(block)
+}
@@ -1,6 +1,10 @@
 package common
 
 import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"testing"
 )
 
@@ -8,16 +12,134 @@ func TestEncrypt(t *testing.T) {
 	secret := "Some secret text"
 	password := "some password"
 
-	output, err := Encrypt(secret, password)
+	for _, kdf := range []KDF{KDFArgon2id, KDFPBKDF2} {
+		t.Run(kdf.String(), func(t *testing.T) {
+			output, err := EncryptWithKDF(secret, password, kdf)
+			if err != nil {
+				t.Fatal("Got err:", err)
+			}
+
+			if output == secret {
+				t.Fatal("output was not encrypted")
+			}
+
+			decrypted, err := Decrypt(output, password)
+			if err != nil {
+				t.Fatal("Got err:", err)
+			}
+
+			if decrypted != secret {
+				t.Fatalf("Expected %q, got %q", secret, decrypted)
+			}
+
+			// Wrong password
+			_, err = Decrypt(output, "wrong password")
+			if err == nil {
+				t.Fatal("Expected decryption to fail")
+			}
+		})
+	}
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	secret := "Some secret text"
+	password := "some password"
+
+	// Legacy layout: salt | nonce | ciphertext, without a header
+	salt := make([]byte, legacySaltSize)
+	_, _ = rand.Read(salt)
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, legacyPBKDF2Iterations, keySize)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	sealed, err := envelope{}.seal(secret, key)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	payload, _ := base64.StdEncoding.DecodeString(sealed)
+	legacy := base64.StdEncoding.EncodeToString(append(salt, payload...))
+
+	decrypted, err := Decrypt(legacy, password)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	if decrypted != secret {
+		t.Fatalf("Expected %q, got %q", secret, decrypted)
+	}
+
+	outdated, err := IsEncryptionOutdated(legacy)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	if !outdated {
+		t.Fatal("Expected legacy value to be outdated")
+	}
+}
+
+func TestIsEncryptionOutdated(t *testing.T) {
+	current, err := EncryptWithKDF("secret", "password", KDFArgon2id)
 	if err != nil {
 		t.Fatal("Got err:", err)
 	}
 
-	if output == secret {
-		t.Fatal("output was not encrypted")
+	if outdated, _ := IsEncryptionOutdated(current); outdated {
+		t.Fatal("Expected value with default kdf to be current")
+	}
+
+	other, err := EncryptWithKDF("secret", "password", KDFPBKDF2)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	if outdated, _ := IsEncryptionOutdated(other); !outdated {
+		t.Fatal("Expected value with non-default kdf to be outdated")
+	}
+}
+
+func TestTamperedHeader(t *testing.T) {
+	output, err := EncryptWithKDF("secret", "password", KDFPBKDF2)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	// Lower the iteration count, header is authenticated so decryption must fail
+	data, _ := base64.StdEncoding.DecodeString(output)
+	data[len(envelopeMagic)+2+3]--
+
+	_, err = Decrypt(base64.StdEncoding.EncodeToString(data), "password")
+	if err == nil {
+		t.Fatal("Expected decryption to fail")
+	}
+}
+
+func TestEncryptWithKey(t *testing.T) {
+	secret := "Some secret text"
+
+	output, err := EncryptWithKDF("verifier", "some password", KDFArgon2id)
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	key, err := DeriveKeyFor(output, "some password")
+	if err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	if _, err := DecryptWithDerivedKey(output, key); err != nil {
+		t.Fatal("Got err:", err)
+	}
+
+	output, err = EncryptWithKey(secret, key)
+	if err != nil {
+		t.Fatal("Got err:", err)
 	}
 
-	decrypted, err := Decrypt(output, password)
+	decrypted, err := DecryptWithKey(output, key)
 	if err != nil {
 		t.Fatal("Got err:", err)
 	}
@@ -26,8 +148,9 @@ func TestEncrypt(t *testing.T) {
 		t.Fatalf("Expected %q, got %q", secret, decrypted)
 	}
 
-	// Wrong password
-	_, err = Decrypt(output, "wrong password")
+	// Wrong key
+	otherKey := make([]byte, keySize)
+	_, err = DecryptWithKey(output, otherKey)
 	if err == nil {
 		t.Fatal("Expected decryption to fail")
 	}
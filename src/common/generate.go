@@ -0,0 +1,74 @@
+package common
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Charsets supported by GenerateSecret
+var Charsets = []string{"alnum", "hex", "base64", "words"}
+
+var charsetAlphabets = map[string]string{
+	"alnum":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"hex":    "0123456789abcdef",
+	"base64": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+}
+
+// wordlist is the BIP39 list of 2048 english words, used to generate passphrases
+//
+//go:embed wordlist.txt
+var wordlistContent string
+
+var wordlist = strings.Fields(wordlistContent)
+
+// GenerateSecret returns a random value of given length from the given charset using crypto/rand.
+// For the "words" charset, length is the number of words, which are joined with dashes.
+func GenerateSecret(charset string, length int) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("length must be positive, got %d", length)
+	}
+
+	if charset == "words" {
+		words := make([]string, length)
+		for i := range words {
+			index, err := randomIndex(len(wordlist))
+			if err != nil {
+				return "", err
+			}
+
+			words[i] = wordlist[index]
+		}
+
+		return strings.Join(words, "-"), nil
+	}
+
+	alphabet, ok := charsetAlphabets[charset]
+	if !ok {
+		return "", fmt.Errorf("unsupported charset %q, options: %s", charset, strings.Join(Charsets, ", "))
+	}
+
+	value := make([]byte, length)
+	for i := range value {
+		index, err := randomIndex(len(alphabet))
+		if err != nil {
+			return "", err
+		}
+
+		value[i] = alphabet[index]
+	}
+
+	return string(value), nil
+}
+
+// randomIndex returns a uniformly random number in [0, n)
+func randomIndex(n int) (int, error) {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(index.Int64()), nil
+}
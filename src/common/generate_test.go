@@ -0,0 +1,53 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	patterns := map[string]*regexp.Regexp{
+		"alnum":  regexp.MustCompile(`^[A-Za-z0-9]{40}$`),
+		"hex":    regexp.MustCompile(`^[0-9a-f]{40}$`),
+		"base64": regexp.MustCompile(`^[A-Za-z0-9_-]{40}$`),
+	}
+
+	for charset, pattern := range patterns {
+		value, err := GenerateSecret(charset, 40)
+		if err != nil {
+			t.Fatalf("GenerateSecret(%q) failed: %v", charset, err)
+		}
+
+		if !pattern.MatchString(value) {
+			t.Errorf("GenerateSecret(%q) = %q does not match %v", charset, value, pattern)
+		}
+	}
+
+	value, err := GenerateSecret("words", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if words := strings.Split(value, "-"); len(words) != 6 {
+		t.Errorf("Expected 6 words, got %q", value)
+	}
+
+	if len(wordlist) != 2048 {
+		t.Errorf("Expected 2048 words in wordlist, got %d", len(wordlist))
+	}
+
+	first, _ := GenerateSecret("alnum", 32)
+	second, _ := GenerateSecret("alnum", 32)
+	if first == second {
+		t.Error("Generated values should differ")
+	}
+
+	if _, err := GenerateSecret("unknown", 32); err == nil {
+		t.Error("Unknown charset should fail")
+	}
+
+	if _, err := GenerateSecret("alnum", 0); err == nil {
+		t.Error("Zero length should fail")
+	}
+}
@@ -29,6 +29,99 @@ var migrations = []string{
 	`CREATE INDEX IF NOT EXISTS idx_store_key_id ON store(key, id);`,
 	// Add is_hidden column (replaces previous hack)
 	`ALTER TABLE store ADD COLUMN is_hidden INTEGER NOT NULL DEFAULT 0`,
+	// Vaults encrypt every key under a prefix with a single password-derived key,
+	// the verifier envelope carries the parameters the key is derived with
+	`
+	CREATE TABLE IF NOT EXISTS vaults (
+		prefix TEXT PRIMARY KEY,
+		verifier TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	// Track when secrets were last rotated, carried over to new records like is_hidden
+	`ALTER TABLE store ADD COLUMN rotated_at DATETIME DEFAULT NULL`,
+	// Links hold the key they point to as their value
+	`ALTER TABLE store ADD COLUMN is_link INTEGER NOT NULL DEFAULT 0`,
+	// Type of each value version, empty for untyped strings
+	`ALTER TABLE store ADD COLUMN type TEXT NOT NULL DEFAULT ''`,
+	// JSON Schemas that values under a prefix must validate against
+	`
+	CREATE TABLE IF NOT EXISTS schemas (
+		prefix TEXT PRIMARY KEY,
+		document TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	// Advisory locks held by scripts, expired leases are cleared like expired values
+	`
+	CREATE TABLE IF NOT EXISTS mutexes (
+		name TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		acquired_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	// Descriptions and tags of keys, kept apart from values so editing them does not add history records
+	`
+	CREATE TABLE IF NOT EXISTS descriptions (
+		key TEXT PRIMARY KEY,
+		description TEXT NOT NULL
+	);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS tags (
+		key TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (key, tag)
+	);
+	`,
+	`CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);`,
+	// Full-text index of keys and values, locked values are indexed as empty and deleted values are left out.
+	// The index is kept up to date by triggers, and rebuilt from the view when needed.
+	`
+	CREATE VIEW IF NOT EXISTS searchable_values AS
+	SELECT id, key, iif(is_locked, '', value) AS value FROM store WHERE value != '';
+	`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS values_fts USING fts5(key, value, content='searchable_values', content_rowid='id', tokenize='trigram');`,
+	`
+	CREATE TRIGGER IF NOT EXISTS values_fts_insert AFTER INSERT ON store WHEN new.value != '' BEGIN
+		INSERT INTO values_fts (rowid, key, value) VALUES (new.id, new.key, iif(new.is_locked, '', new.value));
+	END;
+	`,
+	`
+	CREATE TRIGGER IF NOT EXISTS values_fts_delete AFTER DELETE ON store WHEN old.value != '' BEGIN
+		INSERT INTO values_fts (values_fts, rowid, key, value) VALUES ('delete', old.id, old.key, iif(old.is_locked, '', old.value));
+	END;
+	`,
+	`
+	CREATE TRIGGER IF NOT EXISTS values_fts_update AFTER UPDATE OF key, value, is_locked ON store BEGIN
+		INSERT INTO values_fts (values_fts, rowid, key, value)
+		SELECT 'delete', old.id, old.key, iif(old.is_locked, '', old.value) WHERE old.value != '';
+
+		INSERT INTO values_fts (rowid, key, value)
+		SELECT new.id, new.key, iif(new.is_locked, '', new.value) WHERE new.value != '';
+	END;
+	`,
+	`INSERT INTO values_fts (values_fts) VALUES ('rebuild');`,
+	// Full-text index of descriptions, kept up to date by triggers like values_fts
+	`CREATE VIRTUAL TABLE IF NOT EXISTS descriptions_fts USING fts5(description, content='descriptions', tokenize='trigram');`,
+	`
+	CREATE TRIGGER IF NOT EXISTS descriptions_fts_insert AFTER INSERT ON descriptions BEGIN
+		INSERT INTO descriptions_fts (rowid, description) VALUES (new.rowid, new.description);
+	END;
+	`,
+	`
+	CREATE TRIGGER IF NOT EXISTS descriptions_fts_delete AFTER DELETE ON descriptions BEGIN
+		INSERT INTO descriptions_fts (descriptions_fts, rowid, description) VALUES ('delete', old.rowid, old.description);
+	END;
+	`,
+	`
+	CREATE TRIGGER IF NOT EXISTS descriptions_fts_update AFTER UPDATE OF description ON descriptions BEGIN
+		INSERT INTO descriptions_fts (descriptions_fts, rowid, description) VALUES ('delete', old.rowid, old.description);
+		INSERT INTO descriptions_fts (rowid, description) VALUES (new.rowid, new.description);
+	END;
+	`,
+	`INSERT INTO descriptions_fts (descriptions_fts) VALUES ('rebuild');`,
 }
 
 func runMigrations(tx *sql.Tx) {
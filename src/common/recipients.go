@@ -0,0 +1,142 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// Values encrypted to recipients are stored as ASCII-armored age files, so they can be decrypted with age itself
+
+// IsRecipientEncrypted reports whether value was encrypted to public key recipients rather than with a password
+func IsRecipientEncrypted(value string) bool {
+	return strings.HasPrefix(value, armor.Header)
+}
+
+// ParseRecipients parses an age public key, or a file listing one public key per line
+func ParseRecipients(arg string) ([]age.Recipient, error) {
+	if strings.HasPrefix(arg, "age1") {
+		return age.ParseRecipients(strings.NewReader(arg))
+	}
+
+	file, err := os.Open(NormalizePath(arg))
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a public key nor a readable recipients file: %w", arg, err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	recipients, err := age.ParseRecipients(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipients file %q: %w", arg, err)
+	}
+
+	return recipients, nil
+}
+
+// EncryptToRecipients encrypts plaintext so that any of the recipients' identities can decrypt it
+func EncryptToRecipients(plaintext string, recipients []age.Recipient) (string, error) {
+	var buffer bytes.Buffer
+
+	armored := armor.NewWriter(&buffer)
+	writer, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.WriteString(writer, plaintext); err != nil {
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	if err := armored.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// DecryptWithIdentities decrypts a value encrypted with EncryptToRecipients
+func DecryptWithIdentities(value string, identities []age.Identity) (string, error) {
+	reader, err := age.Decrypt(armor.NewReader(strings.NewReader(value)), identities...)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// ErrNoIdentity is returned when the identity file does not exist
+var ErrNoIdentity = errors.New("no identity found")
+
+// GetIdentityPath returns the path of the identity file, which is kept next to the config rather than in the database
+func GetIdentityPath() string {
+	if identityPath := ReadConfig().Identity; identityPath != "" {
+		return NormalizePath(identityPath)
+	}
+
+	scope := gap.NewScope(gap.User, "kv")
+
+	identityPath, err := scope.ConfigPath("identity.txt")
+	FailOn(err)
+
+	return identityPath
+}
+
+// ReadIdentities reads the identities in the identity file, returns ErrNoIdentity if it does not exist
+func ReadIdentities() ([]age.Identity, error) {
+	file, err := os.Open(GetIdentityPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoIdentity
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	return age.ParseIdentities(file)
+}
+
+// GenerateIdentity creates a new X25519 identity file at path, it fails if the file already exists
+func GenerateIdentity(path string) (*age.X25519Identity, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	_, err = fmt.Fprintf(file, "# public key: %s\n%s\n", identity.Recipient(), identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
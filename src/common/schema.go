@@ -0,0 +1,79 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL is the location schemas are compiled under, they are never fetched from it
+const schemaURL = "kv://schema.json"
+
+// CompileSchema parses and compiles a JSON Schema document
+func CompileSchema(document string) (*jsonschema.Schema, error) {
+	parsed, err := jsonschema.UnmarshalJSON(strings.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, parsed); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(schemaURL)
+}
+
+// ValidateWithSchema validates a value against a compiled schema. Typed values are validated as their typed form
+// (see TypedValue), untyped values are validated as JSON if they parse as JSON and as strings otherwise,
+// so "8080" matches {"type": "integer"} and "localhost" matches {"type": "string"}.
+func ValidateWithSchema(schema *jsonschema.Schema, valueType string, value string) error {
+	var instance any
+	switch valueType {
+	case "", "json":
+		parsed, err := jsonschema.UnmarshalJSON(strings.NewReader(value))
+		if err != nil {
+			if valueType == "json" {
+				return fmt.Errorf("invalid JSON: %w", err)
+			}
+
+			parsed = value
+		}
+
+		instance = parsed
+	default:
+		instance = TypedValue(valueType, value)
+	}
+
+	err := schema.Validate(instance)
+
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return errors.New(schemaErrorMessage(validationErr))
+	}
+
+	return err
+}
+
+// schemaErrorMessage flattens a validation error into its leaf messages
+func schemaErrorMessage(err *jsonschema.ValidationError) string {
+	if len(err.Causes) == 0 {
+		message := err.Error()
+
+		// Drop the "jsonschema validation failed with ..." header, keeping the reason
+		if _, reason, found := strings.Cut(message, "\n- "); found {
+			message = reason
+		}
+
+		return strings.TrimSpace(message)
+	}
+
+	messages := make([]string, 0, len(err.Causes))
+	for _, cause := range err.Causes {
+		messages = append(messages, schemaErrorMessage(cause))
+	}
+
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+type secretPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// Well-known credential formats, checked in order
+var secretPatterns = []secretPattern{
+	{"Private key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"Age secret key", regexp.MustCompile(`\bAGE-SECRET-KEY-1[0-9A-Z]{58}\b`)},
+}
+
+// Candidates for high-entropy detection: long runs of token-like characters
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+const (
+	minTokenEntropy = 4.0 // bits per character, random base64 is close to 6, English words stay below 4
+	minHexEntropy   = 3.0 // random hex is close to 4
+	minHexLength    = 32
+)
+
+var hexPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// DetectSecret reports whether value looks like a credential, and what kind of credential it looks like
+func DetectSecret(value string) (string, bool) {
+	for _, secretPattern := range secretPatterns {
+		if secretPattern.pattern.MatchString(value) {
+			return secretPattern.kind, true
+		}
+	}
+
+	for _, token := range tokenPattern.FindAllString(value, -1) {
+		if isHighEntropyToken(token) {
+			return "High-entropy string", true
+		}
+	}
+
+	return "", false
+}
+
+func isHighEntropyToken(token string) bool {
+	entropy := shannonEntropy(token)
+
+	if hexPattern.MatchString(token) {
+		return len(token) >= minHexLength && entropy >= minHexEntropy
+	}
+
+	// Identifiers like some_long_variable_name are not secrets, random tokens mix character classes
+	hasLower := strings.ContainsAny(token, "abcdefghijklmnopqrstuvwxyz")
+	hasUpper := strings.ContainsAny(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	hasDigit := strings.ContainsAny(token, "0123456789")
+	if !(hasLower && hasUpper && hasDigit) {
+		return false
+	}
+
+	return entropy >= minTokenEntropy
+}
+
+// shannonEntropy returns the average number of bits of information per character of s
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	length := 0
+	for _, r := range s {
+		counts[r]++
+		length++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(length)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP holds the parameters of an RFC 6238 time-based one-time password
+type TOTP struct {
+	Secret    []byte
+	Digits    int
+	Period    time.Duration
+	Algorithm string
+}
+
+// totpScheme prefixes values stored with 'kv set --totp'
+const totpScheme = "otpauth://totp/"
+
+var totpAlgorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// IsTOTP reports whether value is an otpauth TOTP URI
+func IsTOTP(value string) bool {
+	return strings.HasPrefix(value, totpScheme)
+}
+
+// NormalizeTOTP validates input, either an otpauth://totp URI or a base32 seed, and returns it as an otpauth URI.
+// Seeds are labeled with given label.
+func NormalizeTOTP(input string, label string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	if strings.HasPrefix(input, "otpauth://") {
+		if _, err := ParseTOTP(input); err != nil {
+			return "", err
+		}
+
+		return input, nil
+	}
+
+	seed := strings.ToUpper(strings.Join(strings.Fields(input), ""))
+	seed = strings.TrimRight(seed, "=")
+	if _, err := decodeTOTPSecret(seed); err != nil {
+		return "", err
+	}
+
+	query := url.Values{"secret": {seed}}
+	return totpScheme + url.PathEscape(label) + "?" + query.Encode(), nil
+}
+
+// ParseTOTP parses an otpauth://totp URI
+func ParseTOTP(uri string) (TOTP, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return TOTP{}, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+
+	if parsed.Scheme != "otpauth" {
+		return TOTP{}, fmt.Errorf("invalid otpauth URI scheme %q", parsed.Scheme)
+	}
+
+	if parsed.Host != "totp" {
+		return TOTP{}, fmt.Errorf("unsupported OTP type %q, only totp is supported", parsed.Host)
+	}
+
+	query := parsed.Query()
+
+	secret, err := decodeTOTPSecret(query.Get("secret"))
+	if err != nil {
+		return TOTP{}, err
+	}
+
+	totp := TOTP{Secret: secret, Digits: 6, Period: 30 * time.Second, Algorithm: "SHA1"}
+
+	if digits := query.Get("digits"); digits != "" {
+		totp.Digits, err = strconv.Atoi(digits)
+		if err != nil || totp.Digits < 6 || totp.Digits > 8 {
+			return TOTP{}, fmt.Errorf("invalid digits %q, must be between 6 and 8", digits)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds <= 0 {
+			return TOTP{}, fmt.Errorf("invalid period %q", period)
+		}
+
+		totp.Period = time.Duration(seconds) * time.Second
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		totp.Algorithm = strings.ToUpper(algorithm)
+		if _, ok := totpAlgorithms[totp.Algorithm]; !ok {
+			return TOTP{}, fmt.Errorf("unsupported algorithm %q, options: SHA1, SHA256, SHA512", algorithm)
+		}
+	}
+
+	return totp, nil
+}
+
+// Code returns the code valid at given time, and how long it stays valid
+func (totp TOTP) Code(at time.Time) (string, time.Duration) {
+	period := int64(totp.Period / time.Second)
+	counter := at.Unix() / period
+
+	mac := hmac.New(totpAlgorithms[totp.Algorithm], totp.Secret)
+	_ = binary.Write(mac, binary.BigEndian, uint64(counter))
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	binaryCode := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range totp.Digits {
+		modulo *= 10
+	}
+
+	remaining := time.Duration((counter+1)*period-at.Unix()) * time.Second
+
+	return fmt.Sprintf("%0*d", totp.Digits, binaryCode%modulo), remaining
+}
+
+func decodeTOTPSecret(seed string) ([]byte, error) {
+	if seed == "" {
+		return nil, errors.New("missing TOTP secret")
+	}
+
+	seed = strings.ToUpper(strings.TrimRight(seed, "="))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(seed)
+	if err != nil || len(secret) == 0 {
+		return nil, errors.New("TOTP secret is not valid base32")
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	vectors := []struct {
+		time      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1234567890, "SHA512", "93441116"},
+		{20000000000, "SHA1", "65353130"},
+	}
+
+	for _, vector := range vectors {
+		seed := base32.StdEncoding.EncodeToString([]byte(secrets[vector.algorithm]))
+		totp, err := ParseTOTP("otpauth://totp/test?secret=" + seed + "&digits=8&algorithm=" + vector.algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, _ := totp.Code(time.Unix(vector.time, 0))
+		if code != vector.code {
+			t.Errorf("%s at %d: got %s, expected %s", vector.algorithm, vector.time, code, vector.code)
+		}
+	}
+}
+
+func TestTOTPRemaining(t *testing.T) {
+	totp, err := ParseTOTP("otpauth://totp/test?secret=GEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, remaining := totp.Code(time.Unix(59, 0)); remaining != time.Second {
+		t.Errorf("Expected 1s remaining, got %v", remaining)
+	}
+
+	if _, remaining := totp.Code(time.Unix(60, 0)); remaining != 30*time.Second {
+		t.Errorf("Expected 30s remaining, got %v", remaining)
+	}
+}
+
+func TestNormalizeTOTP(t *testing.T) {
+	uri, err := NormalizeTOTP("gezd gnbv gy3t qojq", "ci-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uri != "otpauth://totp/ci-bot?secret=GEZDGNBVGY3TQOJQ" {
+		t.Errorf("Unexpected URI %q", uri)
+	}
+
+	if !IsTOTP(uri) {
+		t.Error("Normalized value should be recognized as TOTP")
+	}
+
+	invalid := []string{
+		"not base32!",
+		"otpauth://hotp/test?secret=GEZDGNBVGY3TQOJQ",
+		"otpauth://totp/test",
+		"otpauth://totp/test?secret=GEZDGNBVGY3TQOJQ&digits=4",
+		"otpauth://totp/test?secret=GEZDGNBVGY3TQOJQ&algorithm=MD5",
+	}
+
+	for _, input := range invalid {
+		if _, err := NormalizeTOTP(input, "test"); err == nil {
+			t.Errorf("NormalizeTOTP(%q) should fail", input)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValueTypes lists the types values can be stored as, values without a type are strings
+var ValueTypes = []string{"string", "int", "bool", "json", "url", "duration"}
+
+// ValidateType fails if valueType is not supported
+func ValidateType(valueType string) error {
+	if valueType != "" && !slices.Contains(ValueTypes, valueType) {
+		return fmt.Errorf("unsupported type %q, options: %s", valueType, strings.Join(ValueTypes, ", "))
+	}
+
+	return nil
+}
+
+// ValidateValue returns an error if value is not a valid value of given type
+func ValidateValue(valueType string, value string) error {
+	if err := ValidateType(valueType); err != nil {
+		return err
+	}
+
+	switch valueType {
+	case "", "string":
+		return nil
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case "json":
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+	case "url":
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+
+		if !parsed.IsAbs() || (parsed.Host == "" && parsed.Opaque == "") {
+			return errors.New("URL must be absolute, e.g. https://example.com")
+		}
+	case "duration":
+		if _, err := ParseDuration(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TypedValue converts value to its Go representation according to valueType: int64 for int, bool for bool,
+// and the decoded value for json. Other types, and values that do not match their type, are returned as strings.
+func TypedValue(valueType string, value string) any {
+	switch valueType {
+	case "int":
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	case "bool":
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case "json":
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateValue(t *testing.T) {
+	valid := map[string][]string{
+		"string":   {"anything", ""},
+		"int":      {"8080", "-1", "0"},
+		"bool":     {"true", "false", "1", "0"},
+		"json":     {`{"port": 8080}`, `[1, 2]`, `"text"`, `null`},
+		"url":      {"https://example.com/path", "postgres://user@localhost/app", "mailto:user@example.com"},
+		"duration": {"1h30m", "90d", "10s"},
+	}
+
+	for valueType, values := range valid {
+		for _, value := range values {
+			if err := ValidateValue(valueType, value); err != nil {
+				t.Errorf("ValidateValue(%q, %q) failed: %v", valueType, value, err)
+			}
+		}
+	}
+
+	invalid := map[string][]string{
+		"int":      {"80.5", "port", ""},
+		"bool":     {"yes", ""},
+		"json":     {`{"port": }`, "text"},
+		"url":      {"/relative/path", "example.com", "https://"},
+		"duration": {"soon", "10"},
+		"float":    {"1.5"},
+	}
+
+	for valueType, values := range invalid {
+		for _, value := range values {
+			if err := ValidateValue(valueType, value); err == nil {
+				t.Errorf("ValidateValue(%q, %q) should fail", valueType, value)
+			}
+		}
+	}
+}
+
+func TestTypedValue(t *testing.T) {
+	cases := []struct {
+		valueType string
+		value     string
+		expected  any
+	}{
+		{"int", "8080", int64(8080)},
+		{"bool", "true", true},
+		{"json", `{"port": 8080}`, map[string]any{"port": float64(8080)}},
+		{"url", "https://example.com", "https://example.com"},
+		{"", "8080", "8080"},
+		{"int", "not a number", "not a number"},
+	}
+
+	for _, c := range cases {
+		if actual := TypedValue(c.valueType, c.value); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("TypedValue(%q, %q) = %#v, expected %#v", c.valueType, c.value, actual, c.expected)
+		}
+	}
+}
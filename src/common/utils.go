@@ -13,11 +13,19 @@ func FailOn(err error) {
 }
 
 func Fail(message string, args ...any) {
+	FailWithCode(1, message, args...)
+}
+
+// ExitConflict is the exit status of conditional writes whose condition does not hold
+const ExitConflict = 3
+
+// FailWithCode is like Fail, but exits with given status
+func FailWithCode(code int, message string, args ...any) {
 	if message != "" {
 		Stderr.Printf(red(message)+"\n", args...)
 	}
 
-	os.Exit(1)
+	os.Exit(code)
 }
 
 func EqualTimePtrs(t1, t2 *time.Time) bool {
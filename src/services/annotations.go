@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// SetDescription sets the description of key, an empty description removes it
+func SetDescription(tx *sql.Tx, key string, description string) {
+	if description == "" {
+		_, err := tx.Exec(`DELETE FROM descriptions WHERE key = ?`, key)
+		common.FailOn(err)
+		return
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO descriptions (key, description) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET description = excluded.description`,
+		key,
+		description,
+	)
+	common.FailOn(err)
+}
+
+func GetDescription(tx *sql.Tx, key string) string {
+	var description string
+	err := tx.QueryRow(`SELECT description FROM descriptions WHERE key = ?`, key).Scan(&description)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+
+	common.FailOn(err)
+	return description
+}
+
+// AddTags tags key with tags, tags key already has are ignored
+func AddTags(tx *sql.Tx, key string, tags ...string) {
+	for _, tag := range tags {
+		_, err := tx.Exec(`INSERT INTO tags (key, tag) VALUES (?, ?) ON CONFLICT DO NOTHING`, key, tag)
+		common.FailOn(err)
+	}
+}
+
+// RemoveTags removes tags from key, tags key does not have are ignored
+func RemoveTags(tx *sql.Tx, key string, tags ...string) {
+	for _, tag := range tags {
+		_, err := tx.Exec(`DELETE FROM tags WHERE key = ? AND tag = ?`, key, tag)
+		common.FailOn(err)
+	}
+}
+
+// GetTags returns the tags of key, sorted
+func GetTags(tx *sql.Tx, key string) []string {
+	rows, err := tx.Query(`SELECT tag FROM tags WHERE key = ? ORDER BY tag`, key)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		common.FailOn(rows.Scan(&tag))
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// ListTags returns all tags in use, sorted
+func ListTags(tx *sql.Tx) []string {
+	rows, err := tx.Query(`SELECT DISTINCT tag FROM tags ORDER BY tag`)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		common.FailOn(rows.Scan(&tag))
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// ListTaggedKeys returns the existing keys that have all of tags, sorted
+func ListTaggedKeys(tx *sql.Tx, tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, len(tags))
+
+	rows, err := tx.Query(`
+		SELECT tags.key
+		FROM tags
+		JOIN store ON store.key = tags.key AND store.is_latest = 1 AND store.value != ''
+		WHERE tags.tag IN (?`+strings.Repeat(", ?", len(tags)-1)+`)
+		GROUP BY tags.key
+		HAVING COUNT(DISTINCT tags.tag) = ?
+		ORDER BY tags.key`,
+		args...,
+	)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		common.FailOn(rows.Scan(&key))
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// renameAnnotations moves the description and tags of oldKey to newKey
+func renameAnnotations(tx *sql.Tx, oldKey string, newKey string) {
+	_, err := tx.Exec(`UPDATE descriptions SET key = ? WHERE key = ?`, newKey, oldKey)
+	common.FailOn(err)
+
+	_, err = tx.Exec(`UPDATE tags SET key = ? WHERE key = ?`, newKey, oldKey)
+	common.FailOn(err)
+}
+
+// annotateItems fills the description and tags of items
+func annotateItems(tx *sql.Tx, items []KVItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	descriptions := map[string]string{}
+	rows, err := tx.Query(`SELECT key, description FROM descriptions`)
+	common.FailOn(err)
+
+	for rows.Next() {
+		var key, description string
+		common.FailOn(rows.Scan(&key, &description))
+		descriptions[key] = description
+	}
+	_ = rows.Close()
+
+	tags := map[string][]string{}
+	rows, err = tx.Query(`SELECT key, tag FROM tags ORDER BY tag`)
+	common.FailOn(err)
+
+	for rows.Next() {
+		var key, tag string
+		common.FailOn(rows.Scan(&key, &tag))
+		tags[key] = append(tags[key], tag)
+	}
+	_ = rows.Close()
+
+	for i := range items {
+		items[i].Description = descriptions[items[i].Key]
+		items[i].Tags = tags[items[i].Key]
+	}
+}
+
+// clearOrphanedAnnotations deletes the descriptions and tags of keys that no longer have any history
+func clearOrphanedAnnotations(tx *sql.Tx) {
+	_, err := tx.Exec(`DELETE FROM descriptions WHERE key NOT IN (SELECT key FROM store)`)
+	common.FailOn(err)
+
+	_, err = tx.Exec(`DELETE FROM tags WHERE key NOT IN (SELECT key FROM store)`)
+	common.FailOn(err)
+}
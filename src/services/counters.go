@@ -0,0 +1,84 @@
+package services
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// IncrementValue adds by to the integer value of key, starting from 0 if key does not exist, and returns the new value.
+// The expiry of key is kept.
+func IncrementValue(tx *sql.Tx, key string, by int64) int64 {
+	item, expiresAt := getPlainItem(tx, key)
+
+	var current int64
+	if item != nil {
+		var err error
+		current, err = strconv.ParseInt(strings.TrimSpace(item.Value), 10, 64)
+		if err != nil {
+			common.Fail("Value of key %q is not an integer", key)
+		}
+	}
+
+	next := current + by
+	if (by > 0 && next < current) || (by < 0 && next > current) {
+		common.Fail("Value of key %q would overflow", key)
+	}
+
+	setPlainValue(tx, key, item, strconv.FormatInt(next, 10), expiresAt)
+	return next
+}
+
+// AppendValue appends text to the value of key, creating it if it does not exist, and returns the new value.
+// The expiry of key is kept.
+func AppendValue(tx *sql.Tx, key string, text string, separator string) string {
+	item, expiresAt := getPlainItem(tx, key)
+
+	value := text
+	if item != nil {
+		value = item.Value + separator + text
+	}
+
+	setPlainValue(tx, key, item, value, expiresAt)
+	return value
+}
+
+// getPlainItem returns the current item of key and its expiry, failing if its value cannot be modified in place
+func getPlainItem(tx *sql.Tx, key string) (*KVItem, *time.Time) {
+	if vault := FindVault(tx, key); vault != nil {
+		common.Fail("Key %q belongs to vault %q, its value cannot be modified in place", key, vault.Prefix)
+	}
+
+	item := GetItem(tx, key)
+	if item == nil {
+		return nil, nil
+	}
+
+	if item.IsLink {
+		common.Fail("Key %q is a link to %q, modify its target instead", key, item.Value)
+	}
+
+	if item.IsLocked {
+		common.Fail("Key %q is locked, its value cannot be modified in place", key)
+	}
+
+	return item, item.ExpiresAt
+}
+
+// setPlainValue validates value against the type of item and the schemas of key, then stores it
+func setPlainValue(tx *sql.Tx, key string, item *KVItem, value string, expiresAt *time.Time) {
+	valueType := ""
+	if item != nil {
+		valueType = item.Type
+	}
+
+	if err := common.ValidateValue(valueType, value); err != nil {
+		common.Fail("Invalid %s value: %v", valueType, err)
+	}
+
+	EnsureSchemas(tx, key, valueType, value)
+	SetValue(tx, key, value, expiresAt, false)
+}
@@ -33,14 +33,14 @@ func GetValue(tx *sql.Tx, key string) (*string, *time.Time) {
 
 func GetItem(tx *sql.Tx, key string) *KVItem {
 	var item KVItem
-	var expiresAt sql.NullTime
+	var expiresAt, rotatedAt sql.NullTime
 
 	err := tx.QueryRow(`
-		SELECT value, timestamp, is_locked, is_hidden, expires_at
+		SELECT id, value, type, timestamp, is_locked, is_hidden, is_link, expires_at, rotated_at
 		FROM store
 		WHERE key = ? AND is_latest = 1 AND value != ''`,
 		key,
-	).Scan(&item.Value, &item.Timestamp, &item.IsLocked, &item.IsHidden, &expiresAt)
+	).Scan(&item.Version, &item.Value, &item.Type, &item.Timestamp, &item.IsLocked, &item.IsHidden, &item.IsLink, &expiresAt, &rotatedAt)
 	if err == sql.ErrNoRows {
 		return nil
 	} else {
@@ -51,9 +51,54 @@ func GetItem(tx *sql.Tx, key string) *KVItem {
 		item.ExpiresAt = &expiresAt.Time
 	}
 
+	if rotatedAt.Valid {
+		item.RotatedAt = &rotatedAt.Time
+	}
+
 	return &item
 }
 
+// GetKeyStats returns the metadata of key, nil if it does not exist.
+// Size is the size of the stored value in bytes, which is the size of the encrypted value for locked keys.
+func GetKeyStats(tx *sql.Tx, key string) *KeyStats {
+	item := GetItem(tx, key)
+	if item == nil {
+		return nil
+	}
+
+	stats := KeyStats{
+		Key:        key,
+		Type:       item.Type,
+		IsLocked:   item.IsLocked,
+		IsHidden:   item.IsHidden,
+		Size:       len(item.Value),
+		Version:    item.Version,
+		ModifiedAt: item.Timestamp,
+		ExpiresAt:  item.ExpiresAt,
+		RotatedAt:  item.RotatedAt,
+	}
+
+	if item.IsLink {
+		stats.LinkTarget = item.Value
+	}
+
+	if vault := FindVault(tx, key); vault != nil {
+		stats.Vault = vault.Prefix
+	}
+
+	stats.Description = GetDescription(tx, key)
+	stats.Tags = GetTags(tx, key)
+
+	err := tx.QueryRow("SELECT COUNT(*) FROM store WHERE key = ?", key).Scan(&stats.Versions)
+	common.FailOn(err)
+
+	// Creation time is that of the oldest history record kept
+	err = tx.QueryRow("SELECT timestamp FROM store WHERE key = ? ORDER BY id ASC LIMIT 1", key).Scan(&stats.CreatedAt)
+	common.FailOn(err)
+
+	return &stats
+}
+
 type MatchType int
 
 const (
@@ -64,7 +109,7 @@ const (
 
 func ListItems(tx *sql.Tx, prefix string, matchType MatchType) []KVItem {
 	query := `
-		SELECT key, value, expires_at, timestamp, is_locked, is_hidden
+		SELECT id, key, value, type, expires_at, timestamp, is_locked, is_hidden, is_link, rotated_at
 		FROM store
 		WHERE key LIKE ? || '%' AND is_latest = 1
 	`
@@ -81,9 +126,12 @@ func ListItems(tx *sql.Tx, prefix string, matchType MatchType) []KVItem {
 
 	rows, err := tx.Query(query, prefix)
 	common.FailOn(err)
-	defer func() { _ = rows.Close() }()
 
-	return parseKVItems(rows)
+	items := parseKVItems(rows)
+	_ = rows.Close()
+
+	annotateItems(tx, items)
+	return items
 }
 
 func ListKeys(tx *sql.Tx, prefix string, matchType MatchType) []string {
@@ -131,7 +179,7 @@ func SearchKeys(tx *sql.Tx, part string, matchType MatchType) []string {
 
 func ListKeyHistory(tx *sql.Tx, key string) []KVItem {
 	rows, err := tx.Query(`
-		SELECT key, value, expires_at, timestamp, is_locked, is_hidden
+		SELECT id, key, value, type, expires_at, timestamp, is_locked, is_hidden, is_link, rotated_at
 		FROM store
 		WHERE key = ?
 		ORDER BY id ASC`,
@@ -142,17 +190,29 @@ func ListKeyHistory(tx *sql.Tx, key string) []KVItem {
 	return parseKVItems(rows)
 }
 
+// HasPlainHistory reports whether older history records of key contain plain-text values
+func HasPlainHistory(tx *sql.Tx, key string) bool {
+	var found bool
+	err := tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM store WHERE key = ? AND is_latest = 0 AND is_locked = 0 AND value != '')",
+		key,
+	).Scan(&found)
+	common.FailOn(err)
+
+	return found
+}
+
 func GetHistoryItem(tx *sql.Tx, key string, steps int) KVItem {
 	var item KVItem
 	err := tx.QueryRow(`
-		SELECT key, value, timestamp, is_locked, is_hidden
+		SELECT key, value, type, timestamp, is_locked, is_hidden, is_link
 		FROM store
 		WHERE key = ?
 		ORDER BY id DESC
 		LIMIT ?, 1`,
 		key,
 		steps,
-	).Scan(&item.Key, &item.Value, &item.Timestamp, &item.IsLocked, &item.IsHidden)
+	).Scan(&item.Key, &item.Value, &item.Type, &item.Timestamp, &item.IsLocked, &item.IsHidden, &item.IsLink)
 	common.FailOn(err)
 
 	return item
@@ -162,15 +222,19 @@ func parseKVItems(rows *sql.Rows) []KVItem {
 	var items []KVItem
 	for rows.Next() {
 		var item KVItem
-		var expiresAt sql.NullTime
+		var expiresAt, rotatedAt sql.NullTime
 
-		err := rows.Scan(&item.Key, &item.Value, &expiresAt, &item.Timestamp, &item.IsLocked, &item.IsHidden)
+		err := rows.Scan(&item.Version, &item.Key, &item.Value, &item.Type, &expiresAt, &item.Timestamp, &item.IsLocked, &item.IsHidden, &item.IsLink, &rotatedAt)
 		common.FailOn(err)
 
 		if expiresAt.Valid {
 			item.ExpiresAt = &expiresAt.Time
 		}
 
+		if rotatedAt.Valid {
+			item.RotatedAt = &rotatedAt.Time
+		}
+
 		items = append(items, item)
 	}
 
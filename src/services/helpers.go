@@ -8,9 +8,12 @@ import (
 )
 
 // RunInTransaction automatically cleans up DB then runs given function, all inside a transaction.
+// An encrypted database is released afterwards, so it's not kept locked between transactions.
 func RunInTransaction(fn func(tx *sql.Tx)) {
 	db, err := common.GetDB()
-	common.FailOn(err)
+	if err != nil {
+		common.Fail("Could not open database: %v", err)
+	}
 
 	tx, err := common.BeginTransaction(db)
 	common.FailOn(err)
@@ -22,16 +25,40 @@ func RunInTransaction(fn func(tx *sql.Tx)) {
 
 	fn(tx)
 
-	err = tx.Commit()
+	err = common.CommitTransaction(db, tx)
 	common.FailOn(err)
+
+	common.ReleaseDB()
+}
+
+// RunInDryTransaction is like RunInTransaction, but rolls back all changes made by given function, to preview them
+func RunInDryTransaction(fn func(tx *sql.Tx)) {
+	db, err := common.GetDB()
+	if err != nil {
+		common.Fail("Could not open database: %v", err)
+	}
+
+	defer common.ReleaseDB()
+
+	tx, err := common.BeginTransaction(db)
+	common.FailOn(err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	cleanupDB(tx)
+
+	fn(tx)
 }
 
-// cleanupDB clears expired values, deletes old history, and prunes old cleared values
+// cleanupDB clears expired values and mutex leases, deletes old history, prunes old cleared values,
+// and clears the annotations of keys that no longer exist
 func cleanupDB(tx *sql.Tx) {
 	config := common.ReadConfig()
 	clearExpiredValues(tx)
+	clearExpiredMutexes(tx)
 	deleteOldHistory(tx, config.HistoryLength)
 	pruneOldClearedValues(tx, config.PruneHistoryAfterDays)
+	clearOrphanedAnnotations(tx)
 }
 
 func clearExpiredValues(tx *sql.Tx) {
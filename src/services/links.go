@@ -0,0 +1,105 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// maxLinkDepth bounds how many links are followed when resolving a key
+const maxLinkDepth = 32
+
+// LinkError is returned when a link cannot be resolved
+type LinkError struct {
+	Chain    []string
+	Dangling bool
+}
+
+func (err *LinkError) Error() string {
+	chain := strings.Join(err.Chain, " -> ")
+	if err.Dangling {
+		return fmt.Sprintf("link %s points to a missing key", chain)
+	}
+
+	return fmt.Sprintf("link cycle %s", chain)
+}
+
+// SetLink makes alias a link to target, replacing the current value or link of alias.
+// Fails if target does not exist or the link would create a cycle.
+func SetLink(tx *sql.Tx, alias string, target string) {
+	if alias == target {
+		common.Fail("Key %q cannot link to itself", alias)
+	}
+
+	current := GetItem(tx, alias)
+	if current != nil && current.IsLink && current.Value == target {
+		return
+	}
+
+	if GetItem(tx, target) == nil {
+		common.Fail("Key %q does not exist", target)
+	}
+
+	// Following the target must never come back to alias
+	chain := []string{alias, target}
+	for key := target; ; {
+		item := GetItem(tx, key)
+		if item == nil || !item.IsLink {
+			break
+		}
+
+		key = item.Value
+		chain = append(chain, key)
+
+		if key == alias {
+			common.Fail("Linking %q to %q creates a cycle: %s", alias, target, strings.Join(chain, " -> "))
+		}
+
+		if len(chain) > maxLinkDepth {
+			common.Fail("Linking %q to %q exceeds %d levels of links", alias, target, maxLinkDepth)
+		}
+	}
+
+	_, err := tx.Exec("UPDATE store SET is_latest = 0 WHERE key = ? AND is_latest = 1", alias)
+	common.FailOn(err)
+
+	isHidden := current != nil && current.IsHidden
+	_, err = tx.Exec(
+		`INSERT INTO store (key, value, is_locked, is_hidden, is_link) VALUES (?, ?, 0, ?, 1)`,
+		alias,
+		target,
+		isHidden,
+	)
+	common.FailOn(err)
+}
+
+// ResolveLink follows links starting at key and returns the key holding the value,
+// key itself is returned if it's not a link or does not exist.
+func ResolveLink(tx *sql.Tx, key string) (string, error) {
+	chain := []string{key}
+
+	for {
+		item := GetItem(tx, key)
+		if item == nil {
+			if len(chain) > 1 {
+				return "", &LinkError{Chain: chain, Dangling: true}
+			}
+
+			return key, nil
+		}
+
+		if !item.IsLink {
+			return key, nil
+		}
+
+		key = item.Value
+		if slices.Contains(chain, key) || len(chain) > maxLinkDepth {
+			return "", &LinkError{Chain: append(chain, key)}
+		}
+
+		chain = append(chain, key)
+	}
+}
@@ -1,21 +1,135 @@
 package services
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"time"
 
+	"github.com/AmrSaber/kv/src/common"
 	"gopkg.in/yaml.v2"
 )
 
 type KVItem struct {
 	Key       string     `json:"key,omitempty" yaml:"key,omitempty"`
 	Value     string     `json:"value,omitempty" yaml:"value,omitempty"`
+	Type      string     `json:"type,omitempty" yaml:"type,omitempty"`
 	IsLocked  bool       `json:"isLocked,omitempty" yaml:"is-locked,omitempty"`
 	IsHidden  bool       `json:"isHidden,omitempty" yaml:"is-hidden,omitempty"`
+	IsLink    bool       `json:"isLink,omitempty" yaml:"is-link,omitempty"`
 	ExpiresAt *time.Time `json:"expiresAt,omitempty" yaml:"expires-at,omitempty"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty" yaml:"rotated-at,omitempty"`
 	Timestamp time.Time  `json:"timestamp" yaml:"timestamp"`
+
+	// Version is the id of the history record holding the value, it changes whenever the value changes
+	Version int64 `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// Description and Tags annotate the key rather than a value, they are only filled when listing keys
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// TypedValue returns the value converted according to its type, e.g. numbers for int values, nil if there is no value
+func (item KVItem) TypedValue() any {
+	if item.Value == "" {
+		return nil
+	}
+
+	if item.IsLocked {
+		return item.Value
+	}
+
+	return common.TypedValue(item.Type, item.Value)
+}
+
+// MarshalJSON outputs typed values, so int values are numbers and json values are nested objects
+func (item KVItem) MarshalJSON() ([]byte, error) {
+	type plainItem KVItem
+
+	// Key and Value shadow the embedded fields, and keep their place at the start of the output
+	return json.Marshal(struct {
+		Key   string `json:"key,omitempty"`
+		Value any    `json:"value,omitempty"`
+		plainItem
+	}{item.Key, item.TypedValue(), plainItem(item)})
+}
+
+// MarshalYAML outputs typed values, like MarshalJSON
+func (item KVItem) MarshalYAML() (any, error) {
+	var fields yaml.MapSlice
+
+	value := reflect.ValueOf(item)
+	for i := range value.NumField() {
+		name, options, _ := strings.Cut(value.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		field := value.Field(i).Interface()
+		if name == "value" {
+			field = item.TypedValue()
+		}
+
+		if options == "omitempty" && (field == nil || value.Field(i).IsZero()) {
+			continue
+		}
+
+		fields = append(fields, yaml.MapItem{Key: name, Value: field})
+	}
+
+	return fields, nil
 }
 
 func (item KVItem) String() string {
 	output, _ := yaml.Marshal(item)
 	return string(output)
 }
+
+// KeyStats is the metadata of a key, without its value
+type KeyStats struct {
+	Key         string     `json:"key" yaml:"key"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Type        string     `json:"type,omitempty" yaml:"type,omitempty"`
+	IsLocked    bool       `json:"isLocked" yaml:"is-locked"`
+	IsHidden    bool       `json:"isHidden" yaml:"is-hidden"`
+	LinkTarget  string     `json:"linkTarget,omitempty" yaml:"link-target,omitempty"`
+	Vault       string     `json:"vault,omitempty" yaml:"vault,omitempty"`
+	Size        int        `json:"size" yaml:"size"`
+	Version     int64      `json:"version" yaml:"version"`
+	Versions    int        `json:"versions" yaml:"versions"`
+	CreatedAt   time.Time  `json:"createdAt" yaml:"created-at"`
+	ModifiedAt  time.Time  `json:"modifiedAt" yaml:"modified-at"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" yaml:"expires-at,omitempty"`
+	RotatedAt   *time.Time `json:"rotatedAt,omitempty" yaml:"rotated-at,omitempty"`
+}
+
+// SearchResult is a key, value, description or older value of a key matching a search
+type SearchResult struct {
+	Key string `json:"key" yaml:"key"`
+	In  string `json:"in" yaml:"in"`
+
+	// Text is the key, value or description that matched
+	Text      string    `json:"text" yaml:"text"`
+	Version   int64     `json:"version" yaml:"version"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+type Vault struct {
+	Prefix    string    `json:"prefix" yaml:"prefix"`
+	Verifier  string    `json:"-" yaml:"-"`
+	CreatedAt time.Time `json:"createdAt" yaml:"created-at"`
+}
+
+type Mutex struct {
+	Name       string    `json:"name" yaml:"name"`
+	Owner      string    `json:"owner" yaml:"owner"`
+	ExpiresAt  time.Time `json:"expiresAt" yaml:"expires-at"`
+	AcquiredAt time.Time `json:"acquiredAt" yaml:"acquired-at"`
+}
+
+type Schema struct {
+	Prefix    string    `json:"prefix" yaml:"prefix"`
+	Document  string    `json:"document" yaml:"document"`
+	CreatedAt time.Time `json:"createdAt" yaml:"created-at"`
+}
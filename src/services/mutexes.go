@@ -0,0 +1,89 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// AcquireMutex takes the mutex name for owner until expiresAt, and returns the current holder of the mutex.
+// The mutex is taken if the returned holder is owner; a mutex already held by owner is renewed.
+func AcquireMutex(tx *sql.Tx, name string, owner string, expiresAt time.Time) Mutex {
+	if mutex := GetMutex(tx, name); mutex != nil && mutex.Owner != owner {
+		return *mutex
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO mutexes (name, owner, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET expires_at = excluded.expires_at`,
+		name,
+		owner,
+		common.FormatTimePtr(&expiresAt),
+	)
+	common.FailOn(err)
+
+	return *GetMutex(tx, name)
+}
+
+// RenewMutex extends the lease of owner on the mutex name until expiresAt, returns false if owner does not hold it
+func RenewMutex(tx *sql.Tx, name string, owner string, expiresAt time.Time) bool {
+	result, err := tx.Exec(
+		`UPDATE mutexes SET expires_at = ? WHERE name = ? AND owner = ?`,
+		common.FormatTimePtr(&expiresAt),
+		name,
+		owner,
+	)
+	common.FailOn(err)
+
+	affected, err := result.RowsAffected()
+	common.FailOn(err)
+
+	return affected > 0
+}
+
+// ReleaseMutex releases the mutex name held by owner, or by anyone if owner is empty.
+// Returns false if the mutex is not held by owner.
+func ReleaseMutex(tx *sql.Tx, name string, owner string) bool {
+	result, err := tx.Exec(`DELETE FROM mutexes WHERE name = ?1 AND (?2 = '' OR owner = ?2)`, name, owner)
+	common.FailOn(err)
+
+	affected, err := result.RowsAffected()
+	common.FailOn(err)
+
+	return affected > 0
+}
+
+func GetMutex(tx *sql.Tx, name string) *Mutex {
+	var mutex Mutex
+	err := tx.QueryRow(
+		`SELECT name, owner, expires_at, acquired_at FROM mutexes WHERE name = ?`,
+		name,
+	).Scan(&mutex.Name, &mutex.Owner, &mutex.ExpiresAt, &mutex.AcquiredAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	common.FailOn(err)
+	return &mutex
+}
+
+func ListMutexes(tx *sql.Tx) []Mutex {
+	rows, err := tx.Query(`SELECT name, owner, expires_at, acquired_at FROM mutexes ORDER BY name`)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var mutexes []Mutex
+	for rows.Next() {
+		var mutex Mutex
+		common.FailOn(rows.Scan(&mutex.Name, &mutex.Owner, &mutex.ExpiresAt, &mutex.AcquiredAt))
+		mutexes = append(mutexes, mutex)
+	}
+
+	return mutexes
+}
+
+func clearExpiredMutexes(tx *sql.Tx) {
+	_, err := tx.Exec(`DELETE FROM mutexes WHERE datetime(expires_at) < CURRENT_TIMESTAMP`)
+	common.FailOn(err)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// SetSchema attaches a JSON Schema document to prefix, replacing any previous one
+func SetSchema(tx *sql.Tx, prefix string, document string) {
+	if prefix == "" {
+		common.Fail("Schema prefix cannot be empty")
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO schemas (prefix, document) VALUES (?, ?)
+		ON CONFLICT(prefix) DO UPDATE SET document = excluded.document, created_at = CURRENT_TIMESTAMP`,
+		prefix,
+		document,
+	)
+	common.FailOn(err)
+}
+
+func GetSchema(tx *sql.Tx, prefix string) *Schema {
+	var schema Schema
+	err := tx.QueryRow(
+		`SELECT prefix, document, created_at FROM schemas WHERE prefix = ?`,
+		prefix,
+	).Scan(&schema.Prefix, &schema.Document, &schema.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	common.FailOn(err)
+	return &schema
+}
+
+// FindSchemas returns the schemas of all prefixes key is under
+func FindSchemas(tx *sql.Tx, key string) []Schema {
+	rows, err := tx.Query(`
+		SELECT prefix, document, created_at
+		FROM schemas
+		WHERE substr(?, 1, length(prefix)) = prefix
+		ORDER BY prefix`,
+		key,
+	)
+	common.FailOn(err)
+
+	return parseSchemas(rows)
+}
+
+func ListSchemas(tx *sql.Tx) []Schema {
+	rows, err := tx.Query(`SELECT prefix, document, created_at FROM schemas ORDER BY prefix`)
+	common.FailOn(err)
+
+	return parseSchemas(rows)
+}
+
+func DeleteSchema(tx *sql.Tx, prefix string) {
+	result, err := tx.Exec(`DELETE FROM schemas WHERE prefix = ?`, prefix)
+	common.FailOn(err)
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		common.Fail("Schema %q does not exist", prefix)
+	}
+}
+
+// ValidateSchemas returns an error if value does not validate against the schemas of all prefixes key is under
+func ValidateSchemas(tx *sql.Tx, key string, valueType string, value string) error {
+	for _, schema := range FindSchemas(tx, key) {
+		compiled, err := common.CompileSchema(schema.Document)
+		if err != nil {
+			return fmt.Errorf("schema %q is invalid: %w", schema.Prefix, err)
+		}
+
+		if err := common.ValidateWithSchema(compiled, valueType, value); err != nil {
+			return fmt.Errorf("does not match schema %q: %w", schema.Prefix, err)
+		}
+	}
+
+	return nil
+}
+
+// EnsureSchemas fails if value does not validate against the schemas of all prefixes key is under
+func EnsureSchemas(tx *sql.Tx, key string, valueType string, value string) {
+	if err := ValidateSchemas(tx, key, valueType, value); err != nil {
+		common.Fail("Key %q %v", key, err)
+	}
+}
+
+func parseSchemas(rows *sql.Rows) []Schema {
+	defer func() { _ = rows.Close() }()
+
+	var schemas []Schema
+	for rows.Next() {
+		var schema Schema
+		common.FailOn(rows.Scan(&schema.Prefix, &schema.Document, &schema.CreatedAt))
+		schemas = append(schemas, schema)
+	}
+
+	return schemas
+}
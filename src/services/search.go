@@ -0,0 +1,105 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// Places a search can look in, see Search
+const (
+	SearchInKeys         = "keys"
+	SearchInValues       = "values"
+	SearchInDescriptions = "desc"
+	SearchInHistory      = "history"
+)
+
+var SearchPlaces = []string{SearchInKeys, SearchInValues, SearchInDescriptions, SearchInHistory}
+
+// Search returns the keys, values, descriptions and older values in history containing query, in the places given by in.
+// Matching is case-insensitive. Locked values are never matched, and hidden values only if showHidden is true.
+// Results are sorted by key, then in the order of in.
+func Search(tx *sql.Tx, query string, in []string, showHidden bool) []SearchResult {
+	var results []SearchResult
+
+	for _, place := range in {
+		switch place {
+		case SearchInKeys:
+			results = append(results, searchQuery(tx, place, `
+				SELECT store.key, store.key, store.id, store.timestamp
+				FROM values_fts
+				JOIN store ON store.id = values_fts.rowid
+				WHERE `+matchColumn("values_fts", "key", query)+` AND store.is_latest = 1
+				ORDER BY store.key`,
+				matchArg(query),
+			)...)
+		case SearchInValues, SearchInHistory:
+			isLatest := place == SearchInValues
+			results = append(results, searchQuery(tx, place, `
+				SELECT store.key, store.value, store.id, store.timestamp
+				FROM values_fts
+				JOIN store ON store.id = values_fts.rowid
+				WHERE `+matchColumn("values_fts", "value", query)+`
+					AND store.is_latest = ? AND store.is_locked = 0 AND (? OR store.is_hidden = 0)
+				ORDER BY store.key, store.id DESC`,
+				matchArg(query), isLatest, showHidden,
+			)...)
+		case SearchInDescriptions:
+			results = append(results, searchQuery(tx, place, `
+				SELECT descriptions.key, descriptions.description, store.id, store.timestamp
+				FROM descriptions_fts
+				JOIN descriptions ON descriptions.rowid = descriptions_fts.rowid
+				JOIN store ON store.key = descriptions.key AND store.is_latest = 1 AND store.value != ''
+				WHERE `+matchColumn("descriptions_fts", "description", query)+`
+				ORDER BY descriptions.key`,
+				matchArg(query),
+			)...)
+		default:
+			panic(fmt.Sprintf("Search place %q is not supported", place))
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int { return strings.Compare(a.Key, b.Key) })
+
+	return results
+}
+
+// matchColumn returns the condition matching query in column of an index. Queries of 3 characters or more
+// use the trigram index, shorter ones cannot and are matched by scanning the index instead.
+func matchColumn(table string, column string, query string) string {
+	if utf8.RuneCountInString(query) >= 3 {
+		return fmt.Sprintf("%s MATCH '{%s}: ' || ?", table, column)
+	}
+
+	return fmt.Sprintf(`%s.%s LIKE '%%' || ? || '%%' ESCAPE '\'`, table, column)
+}
+
+// matchArg returns query as the argument of the condition returned by matchColumn
+func matchArg(query string) string {
+	if utf8.RuneCountInString(query) >= 3 {
+		// Matched as a single phrase, so the query is never parsed as FTS5 syntax
+		return `"` + strings.ReplaceAll(query, `"`, `""`) + `"`
+	}
+
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+}
+
+func searchQuery(tx *sql.Tx, place string, query string, args ...any) []SearchResult {
+	rows, err := tx.Query(query, args...)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var results []SearchResult
+	for rows.Next() {
+		result := SearchResult{In: place}
+		common.FailOn(rows.Scan(&result.Key, &result.Text, &result.Version, &result.Timestamp))
+		results = append(results, result)
+	}
+
+	common.FailOn(rows.Err())
+	return results
+}
@@ -7,73 +7,94 @@ import (
 	"github.com/AmrSaber/kv/src/common"
 )
 
+// SetValue stores value as the latest value of key, keeping its hidden state and type.
+// The rotation time is only kept if the value does not change (e.g. only its expiry does), a new value was not
+// rotated by 'kv rotate' or 'kv gen', which mark it afterwards.
+// An empty value deletes key, dropping its description and tags so a recreated key starts without them.
 func SetValue(tx *sql.Tx, key string, value string, expiresAt *time.Time, isLocked bool) {
-	// Skip write if attempting to write identical values
+	// Get current hidden state, type and rotation time to preserve them
+	currentItem := GetItem(tx, key)
+
+	// Skip write if attempting to write identical values, a link holding the value as its target is replaced
 	currentValue, currentExpiry := GetValue(tx, key)
-	if common.EqualStringPtrs(currentValue, &value) && common.EqualTimePtrs(currentExpiry, expiresAt) {
+	isLink := currentItem != nil && currentItem.IsLink
+	if common.EqualStringPtrs(currentValue, &value) && common.EqualTimePtrs(currentExpiry, expiresAt) && !isLink {
 		return
 	}
 
-	// Get current hidden state to preserve it
-	currentItem := GetItem(tx, key)
-	isHidden := false
+	isHidden, valueType := false, ""
+	var rotatedAt *time.Time
 	if currentItem != nil {
 		isHidden = currentItem.IsHidden
+		valueType = currentItem.Type
+
+		if currentItem.Value == value {
+			rotatedAt = currentItem.RotatedAt
+		}
 	}
 
 	_, err := tx.Exec("UPDATE store SET is_latest = 0 WHERE key = ? AND is_latest = 1", key)
 	common.FailOn(err)
 
 	_, err = tx.Exec(
-		`INSERT INTO store (key, value, is_locked, is_hidden, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		`INSERT INTO store (key, value, type, is_locked, is_hidden, expires_at, rotated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		key,
 		value,
+		valueType,
 		isLocked,
 		isHidden,
 		common.FormatTimePtr(expiresAt),
+		common.FormatTimePtr(rotatedAt),
 	)
 	common.FailOn(err)
+
+	if value == "" {
+		SetDescription(tx, key, "")
+		RemoveTags(tx, key, GetTags(tx, key)...)
+	}
 }
 
+// PruneKey deletes key with its history, description and tags
 func PruneKey(tx *sql.Tx, key string) {
 	_, err := tx.Exec("DELETE FROM store WHERE key = ?", key)
 	common.FailOn(err)
+
+	SetDescription(tx, key, "")
+	RemoveTags(tx, key, GetTags(tx, key)...)
 }
 
-func LockKey(tx *sql.Tx, key string, password string) {
+// LockKey replaces the plain value of key with its encrypted form, as returned by encrypt
+func LockKey(tx *sql.Tx, key string, encrypt func(value string) (string, error)) {
 	item := GetItem(tx, key)
 	if item == nil {
 		common.Fail("Key %q does not exist", key)
 		return // To shut up the compiler
 	}
 
+	if item.IsLink {
+		common.Fail("Key %q is a link to %q, lock its target instead", key, item.Value)
+	}
+
 	if item.IsLocked {
 		common.Fail("Key %q is already locked, unlock it first", key)
 	}
 
-	// Preserve hidden state before deleting
-	isHidden := item.IsHidden
-
-	// Delete existing record so value is no longer in history
-	_, err := tx.Exec("DELETE FROM store WHERE key = ? AND is_latest = 1", key)
+	encryptedValue, err := encrypt(item.Value)
 	common.FailOn(err)
 
-	encryptedValue, err := common.Encrypt(item.Value, password)
-	common.FailOn(err)
+	replaceLatestValue(tx, key, *item, encryptedValue, true)
+}
 
-	// Insert the new encrypted value with preserved hidden state
-	_, err = tx.Exec(
-		`INSERT INTO store (key, value, is_locked, is_hidden, expires_at) VALUES (?, ?, ?, ?, ?)`,
-		key,
-		encryptedValue,
-		true,
-		isHidden,
-		common.FormatTimePtr(item.ExpiresAt),
-	)
-	common.FailOn(err)
+// UnlockKey replaces the locked value of key with its plain text, as returned by decrypt
+func UnlockKey(tx *sql.Tx, key string, decrypt func(value string) (string, error)) error {
+	if vault := FindVault(tx, key); vault != nil {
+		common.Fail("Key %q belongs to vault %q, use 'kv vault remove' to decrypt it", key, vault.Prefix)
+	}
+
+	return unlockKey(tx, key, decrypt)
 }
 
-func UnlockKey(tx *sql.Tx, key string, password string) error {
+func unlockKey(tx *sql.Tx, key string, decrypt func(value string) (string, error)) error {
 	item := GetItem(tx, key)
 	if item == nil {
 		common.Fail("Key %q does not exist", key)
@@ -84,30 +105,160 @@ func UnlockKey(tx *sql.Tx, key string, password string) error {
 		common.Fail("Key %q is not locked", key)
 	}
 
-	// Preserve hidden state before deleting
-	isHidden := item.IsHidden
+	decryptedValue, err := decrypt(item.Value)
+	if err != nil {
+		return err
+	}
+
+	replaceLatestValue(tx, key, *item, decryptedValue, false)
 
-	// Delete existing record so value is no longer in history
-	_, err := tx.Exec("DELETE FROM store WHERE key = ? AND is_latest = 1", key)
-	common.FailOn(err)
+	return nil
+}
+
+// ReencryptKey replaces the locked value of key in place with the output of reencrypt, without creating a history entry
+func ReencryptKey(tx *sql.Tx, key string, reencrypt func(value string) (string, error)) error {
+	item := GetItem(tx, key)
+	if item == nil {
+		common.Fail("Key %q does not exist", key)
+		return nil // To shut up the compiler
+	}
+
+	if !item.IsLocked {
+		common.Fail("Key %q is not locked", key)
+	}
 
-	decryptedValue, err := common.Decrypt(item.Value, password)
+	newValue, err := reencrypt(item.Value)
 	if err != nil {
 		return err
 	}
 
-	// Insert the new decrypted value with preserved hidden state
+	_, err = tx.Exec("UPDATE store SET value = ? WHERE key = ? AND is_latest = 1", newValue, key)
+	common.FailOn(err)
+
+	return nil
+}
+
+// ReencryptHistory replaces locked values in older history records of key in place with the output of reencrypt,
+// records that reencrypt fails for (e.g. locked with another password) are left as they are.
+// Returns the number of re-encrypted records.
+func ReencryptHistory(tx *sql.Tx, key string, reencrypt func(value string) (string, error)) int {
+	rows, err := tx.Query(
+		"SELECT id, value FROM store WHERE key = ? AND is_latest = 0 AND is_locked = 1",
+		key,
+	)
+	common.FailOn(err)
+
+	values := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var value string
+		common.FailOn(rows.Scan(&id, &value))
+		values[id] = value
+	}
+	common.FailOn(rows.Err())
+	_ = rows.Close()
+
+	count := 0
+	for id, value := range values {
+		newValue, err := reencrypt(value)
+		if err != nil {
+			continue
+		}
+
+		_, err = tx.Exec("UPDATE store SET value = ? WHERE id = ?", newValue, id)
+		common.FailOn(err)
+
+		count++
+	}
+
+	return count
+}
+
+// ScrubKeyHistory removes plain-text values from older history records of key. If encrypt is given, values are replaced
+// in place with their encrypted form, otherwise the records are deleted. Returns the number of scrubbed records.
+func ScrubKeyHistory(tx *sql.Tx, key string, encrypt func(value string) (string, error)) int {
+	rows, err := tx.Query(
+		"SELECT id, value FROM store WHERE key = ? AND is_latest = 0 AND is_locked = 0 AND value != ''",
+		key,
+	)
+	common.FailOn(err)
+
+	values := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var value string
+		common.FailOn(rows.Scan(&id, &value))
+		values[id] = value
+	}
+	common.FailOn(rows.Err())
+	_ = rows.Close()
+
+	for id, value := range values {
+		if encrypt == nil {
+			_, err = tx.Exec("DELETE FROM store WHERE id = ?", id)
+			common.FailOn(err)
+			continue
+		}
+
+		encryptedValue, err := encrypt(value)
+		common.FailOn(err)
+
+		_, err = tx.Exec("UPDATE store SET value = ?, is_locked = 1 WHERE id = ?", encryptedValue, id)
+		common.FailOn(err)
+	}
+
+	return len(values)
+}
+
+// SealKey encrypts key's value with the key of the vault it belongs to
+func SealKey(tx *sql.Tx, key string, vaultKey []byte) {
+	item := GetItem(tx, key)
+	if item == nil {
+		common.Fail("Key %q does not exist", key)
+		return // To shut up the compiler
+	}
+
+	encryptedValue, err := common.EncryptWithKey(item.Value, vaultKey)
+	common.FailOn(err)
+
+	replaceLatestValue(tx, key, *item, encryptedValue, true)
+}
+
+// replaceLatestValue swaps the latest record of key with given value, so the old value is no longer in history
+func replaceLatestValue(tx *sql.Tx, key string, item KVItem, value string, isLocked bool) {
+	_, err := tx.Exec("DELETE FROM store WHERE key = ? AND is_latest = 1", key)
+	common.FailOn(err)
+
+	// Insert the new value with preserved hidden state, link state, expiry and rotation time
 	_, err = tx.Exec(
-		`INSERT INTO store (key, value, is_locked, is_hidden, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		`INSERT INTO store (key, value, type, is_locked, is_hidden, is_link, expires_at, rotated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		key,
-		decryptedValue,
-		false,
-		isHidden,
+		value,
+		item.Type,
+		isLocked,
+		item.IsHidden,
+		item.IsLink,
 		common.FormatTimePtr(item.ExpiresAt),
+		common.FormatTimePtr(item.RotatedAt),
 	)
 	common.FailOn(err)
+}
 
-	return nil
+// MarkRotated records that the latest value of key was rotated at the current time
+func MarkRotated(tx *sql.Tx, key string) {
+	now := time.Now()
+	_, err := tx.Exec(
+		"UPDATE store SET rotated_at = ? WHERE key = ? AND is_latest = 1",
+		common.FormatTimePtr(&now),
+		key,
+	)
+	common.FailOn(err)
+}
+
+// SetValueType sets the type of the latest value of key, validation is up to the caller
+func SetValueType(tx *sql.Tx, key string, valueType string) {
+	_, err := tx.Exec("UPDATE store SET type = ? WHERE key = ? AND is_latest = 1", valueType, key)
+	common.FailOn(err)
 }
 
 func HideKey(tx *sql.Tx, key string) {
@@ -155,6 +306,8 @@ func RenameKey(tx *sql.Tx, oldKey string, newKey string) {
 		common.Fail("Key %q does not exist", oldKey)
 	}
 
+	EnsureSameVault(tx, oldKey, newKey)
+
 	// Check if new key already exists
 	newItem := GetItem(tx, newKey)
 	if newItem != nil {
@@ -164,4 +317,6 @@ func RenameKey(tx *sql.Tx, oldKey string, newKey string) {
 	// Rename the key across all history items
 	_, err := tx.Exec("UPDATE store SET key = ? WHERE key = ?", newKey, oldKey)
 	common.FailOn(err)
+
+	renameAnnotations(tx, oldKey, newKey)
 }
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// vaultVerifierText is encrypted with the vault key on creation, so that derived keys can be checked before use
+const vaultVerifierText = "kv-vault"
+
+var ErrWrongPassword = errors.New("wrong password")
+
+// CreateVault creates a vault for prefix and encrypts all existing keys under it, returns the vault key
+func CreateVault(tx *sql.Tx, prefix string, password string) []byte {
+	if prefix == "" {
+		common.Fail("Vault prefix cannot be empty")
+	}
+
+	var overlapping string
+	err := tx.QueryRow(`
+		SELECT prefix
+		FROM vaults
+		WHERE substr(?1, 1, length(prefix)) = prefix OR substr(prefix, 1, length(?1)) = ?1
+		LIMIT 1`,
+		prefix,
+	).Scan(&overlapping)
+	if err != sql.ErrNoRows {
+		common.FailOn(err)
+		common.Fail("Vault %q overlaps with existing vault %q", prefix, overlapping)
+	}
+
+	// The verifier envelope carries the vault's KDF parameters, the vault key is the key derived for it
+	verifier, err := common.Encrypt(vaultVerifierText, password)
+	common.FailOn(err)
+
+	key, err := common.DeriveKeyFor(verifier, password)
+	common.FailOn(err)
+
+	_, err = tx.Exec(
+		`INSERT INTO vaults (prefix, verifier) VALUES (?, ?)`,
+		prefix,
+		verifier,
+	)
+	common.FailOn(err)
+
+	for _, item := range ListItems(tx, prefix, MatchExisting) {
+		// Prefix listing is case-insensitive, vault membership is not
+		if !strings.HasPrefix(item.Key, prefix) {
+			continue
+		}
+
+		// Links only hold the name of their target
+		if item.IsLink {
+			continue
+		}
+
+		if item.IsLocked {
+			common.Fail("Key %q is locked, unlock it before adding it to a vault", item.Key)
+		}
+
+		SealKey(tx, item.Key, key)
+	}
+
+	return key
+}
+
+func GetVault(tx *sql.Tx, prefix string) *Vault {
+	var vault Vault
+	err := tx.QueryRow(
+		`SELECT prefix, verifier, created_at FROM vaults WHERE prefix = ?`,
+		prefix,
+	).Scan(&vault.Prefix, &vault.Verifier, &vault.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	common.FailOn(err)
+	return &vault
+}
+
+// FindVault returns the vault that key belongs to, if any
+func FindVault(tx *sql.Tx, key string) *Vault {
+	var vault Vault
+	err := tx.QueryRow(`
+		SELECT prefix, verifier, created_at
+		FROM vaults
+		WHERE substr(?, 1, length(prefix)) = prefix`,
+		key,
+	).Scan(&vault.Prefix, &vault.Verifier, &vault.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	common.FailOn(err)
+	return &vault
+}
+
+func ListVaults(tx *sql.Tx) []Vault {
+	rows, err := tx.Query(`SELECT prefix, verifier, created_at FROM vaults ORDER BY prefix`)
+	common.FailOn(err)
+	defer func() { _ = rows.Close() }()
+
+	var vaults []Vault
+	for rows.Next() {
+		var vault Vault
+		common.FailOn(rows.Scan(&vault.Prefix, &vault.Verifier, &vault.CreatedAt))
+		vaults = append(vaults, vault)
+	}
+
+	return vaults
+}
+
+// DeleteVault decrypts all keys under the vault and removes it
+func DeleteVault(tx *sql.Tx, vault Vault, vaultKey []byte) {
+	for _, item := range ListItems(tx, vault.Prefix, MatchExisting) {
+		if !strings.HasPrefix(item.Key, vault.Prefix) || !item.IsLocked {
+			continue
+		}
+
+		err := unlockKey(tx, item.Key, func(value string) (string, error) {
+			return common.DecryptWithKey(value, vaultKey)
+		})
+		if err != nil {
+			common.Fail("Could not decrypt key %q with vault key", item.Key)
+		}
+	}
+
+	_, err := tx.Exec(`DELETE FROM vaults WHERE prefix = ?`, vault.Prefix)
+	common.FailOn(err)
+}
+
+// EnsureSameVault fails if keys do not belong to the same vault, as values cannot move between vaults as-is
+func EnsureSameVault(tx *sql.Tx, fromKey string, toKey string) {
+	fromVault, toVault := FindVault(tx, fromKey), FindVault(tx, toKey)
+	if fromVault == nil && toVault == nil {
+		return
+	}
+
+	if fromVault == nil || toVault == nil || fromVault.Prefix != toVault.Prefix {
+		common.Fail("Cannot move values across vault boundaries (%q -> %q)", fromKey, toKey)
+	}
+}
+
+// DeriveKey derives the vault key from password, returns ErrWrongPassword if it does not match the vault
+func (vault Vault) DeriveKey(password string) ([]byte, error) {
+	key, err := common.DeriveKeyFor(vault.Verifier, password)
+	if err != nil || !vault.Verify(key) {
+		return nil, ErrWrongPassword
+	}
+
+	return key, nil
+}
+
+// Verify checks that key is this vault's key
+func (vault Vault) Verify(key []byte) bool {
+	text, err := common.DecryptWithDerivedKey(vault.Verifier, key)
+	return err == nil && text == vaultVerifierText
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+// setupAgent starts the agent, and makes sure it's stopped when the test ends
+func setupAgent(t *testing.T) {
+	t.Helper()
+
+	RunKVSuccess(t, "agent", "start")
+
+	t.Cleanup(func() {
+		_, _ = RunKV(t, "agent", "stop")
+	})
+}
+
+func TestAgentLifecycle(t *testing.T) {
+	t.Run("start, status and stop", func(t *testing.T) {
+		SetupTestDB(t)
+
+		output := RunKVFailure(t, "agent", "status")
+		if !strings.Contains(output, "not running") {
+			t.Errorf("Expected 'not running' error, got: %s", output)
+		}
+
+		setupAgent(t)
+
+		output = RunKVSuccess(t, "agent", "status")
+		if !strings.Contains(output, "0 cached") {
+			t.Errorf("Expected empty running agent, got: %s", output)
+		}
+
+		output = RunKVFailure(t, "agent", "start")
+		if !strings.Contains(output, "already running") {
+			t.Errorf("Expected 'already running' error, got: %s", output)
+		}
+
+		RunKVSuccess(t, "agent", "stop")
+		RunKVFailure(t, "agent", "status")
+	})
+}
+
+func TestAgentCaching(t *testing.T) {
+	t.Run("get uses cached key", func(t *testing.T) {
+		SetupTestDB(t)
+		setupAgent(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+
+		// Nothing cached yet
+		RunKVFailure(t, "get", "secret")
+
+		// Wrong passwords are not cached
+		RunKVFailure(t, "get", "secret", "--password=wrong")
+		RunKVFailure(t, "get", "secret")
+
+		RunKVSuccess(t, "get", "secret", "--password=pass")
+
+		output := RunKVSuccess(t, "get", "secret")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		RunKVSuccess(t, "agent", "lock")
+
+		output = RunKVFailure(t, "get", "secret")
+		if !strings.Contains(output, "locked") {
+			t.Errorf("Expected locked error, got: %s", output)
+		}
+	})
+
+	t.Run("unlock uses cached key", func(t *testing.T) {
+		SetupTestDB(t)
+		setupAgent(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+		RunKVSuccess(t, "get", "secret", "--password=pass")
+
+		RunKVSuccess(t, "unlock", "secret")
+
+		output := RunKVSuccess(t, "list", "secret")
+		if strings.Contains(output, "[Locked]") {
+			t.Error("Key should not be locked after unlock")
+		}
+	})
+
+	t.Run("cached key does not decrypt new values", func(t *testing.T) {
+		SetupTestDB(t)
+		setupAgent(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+		RunKVSuccess(t, "get", "secret", "--password=pass")
+
+		RunKVSuccess(t, "set", "secret", "other", "--password=other")
+		RunKVFailure(t, "get", "secret")
+	})
+
+	t.Run("nothing is cached without an agent", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+		RunKVSuccess(t, "get", "secret", "--password=pass")
+		RunKVFailure(t, "get", "secret")
+	})
+
+	t.Run("vaults use running agent", func(t *testing.T) {
+		SetupTestDB(t)
+		setupAgent(t)
+		RunKVSuccess(t, "vault", "create", "secrets.", "--password=pass")
+		RunKVSuccess(t, "vault", "unlock", "secrets.", "--password=pass")
+		RunKVSuccess(t, "set", "secrets.api", "key1")
+
+		output := RunKVSuccess(t, "agent", "status")
+		if !strings.Contains(output, "1 cached") {
+			t.Errorf("Expected vault key in agent, got: %s", output)
+		}
+
+		RunKVSuccess(t, "agent", "lock")
+		RunKVFailure(t, "get", "secrets.api")
+	})
+}
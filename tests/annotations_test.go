@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	t.Run("descriptions and tags do not add history", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.pass", "s3cr3t", "--desc", "prod RDS master", "--tag", "prod", "--tag", "db")
+		version := getVersion(t, "db.pass")
+
+		output := RunKVSuccess(t, "stat", "db.pass", "-o", "yaml")
+		if !strings.Contains(output, "description: prod RDS master") || !strings.Contains(output, "- db\n- prod") {
+			t.Errorf("Expected description and tags, got: %s", output)
+		}
+
+		RunKVSuccess(t, "annotate", "db.pass", "--desc", "changed", "--untag", "db", "--tag", "rds")
+
+		output = RunKVSuccess(t, "list", "-o", "json")
+		if !strings.Contains(output, `"description": "changed"`) || !strings.Contains(output, `"rds"`) || strings.Contains(output, `"db"`) {
+			t.Errorf("Expected updated annotations, got: %s", output)
+		}
+
+		if getVersion(t, "db.pass") != version {
+			t.Errorf("Annotating a key should not change its version")
+		}
+
+		output = RunKVSuccess(t, "history", "list", "db.pass", "-o", "json")
+		if strings.Count(output, `"value"`) != 1 {
+			t.Errorf("Expected a single version in history, got: %s", output)
+		}
+
+		RunKVSuccess(t, "annotate", "db.pass", "--desc", "")
+		if output := RunKVSuccess(t, "stat", "db.pass", "-o", "yaml"); strings.Contains(output, "description") {
+			t.Errorf("Expected description to be removed, got: %s", output)
+		}
+
+		RunKVFailure(t, "annotate", "db.pass")
+		RunKVFailure(t, "annotate", "missing", "--tag", "prod")
+		RunKVFailure(t, "set", "key", "value", "--tag", "has space")
+	})
+
+	t.Run("list by tag", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.pass", "s3cr3t", "--tag", "prod", "--tag", "db")
+		RunKVSuccess(t, "set", "db.host", "localhost", "--tag", "prod")
+		RunKVSuccess(t, "set", "other", "value")
+
+		output := RunKVSuccess(t, "list", "--tag", "prod", "--no-values")
+		if !strings.Contains(output, "db.pass") || !strings.Contains(output, "db.host") || strings.Contains(output, "other") {
+			t.Errorf("Expected keys tagged prod, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "list", "--tag", "prod", "--tag", "db", "--no-values")
+		if !strings.Contains(output, "db.pass") || strings.Contains(output, "db.host") {
+			t.Errorf("Expected keys tagged prod and db, got: %s", output)
+		}
+	})
+
+	t.Run("annotations follow renames and are pruned with keys", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "old", "value", "--desc", "some key", "--tag", "prod")
+		RunKVSuccess(t, "rename", "old", "new")
+
+		if output := RunKVSuccess(t, "stat", "new", "-o", "yaml"); !strings.Contains(output, "description: some key") {
+			t.Errorf("Expected description to follow rename, got: %s", output)
+		}
+
+		RunKVSuccess(t, "delete", "new", "--prune")
+		RunKVSuccess(t, "set", "new", "value")
+
+		if output := RunKVSuccess(t, "stat", "new", "-o", "yaml"); strings.Contains(output, "description") || strings.Contains(output, "prod") {
+			t.Errorf("Expected annotations to be pruned with the key, got: %s", output)
+		}
+	})
+
+	t.Run("deleted keys are recreated without annotations", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "a", "1", "--desc", "old key", "--tag", "prod")
+		RunKVSuccess(t, "delete", "a")
+		RunKVSuccess(t, "set", "a", "new")
+
+		if output := RunKVSuccess(t, "stat", "a", "-o", "yaml"); strings.Contains(output, "old key") || strings.Contains(output, "prod") {
+			t.Errorf("Expected recreated key to have no annotations, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "list", "--tag", "prod", "--no-values"); strings.Contains(output, " a ") {
+			t.Errorf("Expected recreated key not to be tagged, got: %s", output)
+		}
+	})
+
+	t.Run("lock, expire and delete by tag", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "a", "1", "--tag", "temp")
+		RunKVSuccess(t, "set", "b", "2", "--tag", "temp")
+		RunKVSuccess(t, "set", "c", "3")
+
+		RunKVFailure(t, "lock", "c", "--tag", "temp", "--password=pass")
+		RunKVSuccess(t, "lock", "--tag", "temp", "--password=pass")
+		RunKVFailure(t, "get", "a")
+		if output := RunKVSuccess(t, "get", "b", "--password=pass"); output != "2" {
+			t.Errorf("Expected 2, got %q", output)
+		}
+
+		RunKVSuccess(t, "expire", "--tag", "temp", "--after", "1h")
+		output := RunKVSuccess(t, "list", "-o", "json")
+		if strings.Count(output, `"expiresAt"`) != 2 {
+			t.Errorf("Expected 2 expiring keys, got: %s", output)
+		}
+
+		RunKVSuccess(t, "delete", "--tag", "temp")
+		output = RunKVSuccess(t, "list", "--no-values")
+		if strings.Contains(output, " a ") || strings.Contains(output, " b ") || !strings.Contains(output, " c ") {
+			t.Errorf("Expected only c to remain, got: %s", output)
+		}
+
+		RunKVFailure(t, "delete")
+		RunKVFailure(t, "expire", "--after", "1h")
+	})
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBatchFile writes given operations to a file and returns its path
+func writeBatchFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("operations are applied", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "app.legacy", "old")
+		RunKVSuccess(t, "set", "app.session", "abc")
+		RunKVSuccess(t, "set", "app.old-name", "value")
+
+		RunKVSuccess(t, "batch", writeBatchFile(t, `
+# Update the app
+set app.port 9090
+set app.motd "hello world" 1h
+delete app.legacy
+expire app.session 30m
+rename app.old-name app.name
+copy app.name app.name-backup
+lock app.port
+`), "--password=pass")
+
+		if output := RunKVSuccess(t, "get", "app.port", "--password=pass"); output != "9090" {
+			t.Errorf("Expected 9090, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "app.motd"); output != "hello world" {
+			t.Errorf("Expected hello world, got %q", output)
+		}
+
+		RunKVFailure(t, "get", "app.legacy")
+		RunKVFailure(t, "get", "app.old-name")
+
+		if output := RunKVSuccess(t, "get", "app.name-backup"); output != "value" {
+			t.Errorf("Expected copied value, got %q", output)
+		}
+
+		output := RunKVSuccess(t, "list", "app.", "-o", "json")
+		if strings.Count(output, `"expiresAt"`) != 2 {
+			t.Errorf("Expected 2 expiring keys, got: %s", output)
+		}
+	})
+
+	t.Run("operations from stdin as JSON", func(t *testing.T) {
+		SetupTestDB(t)
+
+		cmd := RunKVCommand(t, "batch", "-")
+		cmd.Stdin = strings.NewReader(`[
+			{"op": "set", "key": "a", "value": "1"},
+			{"op": "rename", "key": "a", "to": "b"}
+		]`)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("batch failed: %v: %s", err, output)
+		}
+
+		if output := RunKVSuccess(t, "get", "b"); output != "1" {
+			t.Errorf("Expected 1, got %q", output)
+		}
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "counter", "1")
+
+		output := RunKVFailure(t, "batch", writeBatchFile(t, "set counter 2\ndelete missing\n"))
+		if !strings.Contains(output, "line 2") {
+			t.Errorf("Expected failing line in output, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "counter"); output != "1" {
+			t.Errorf("Expected no changes to be applied, got %q", output)
+		}
+
+		// Invalid operations are reported before anything runs
+		output = RunKVFailure(t, "batch", writeBatchFile(t, "set counter 3\nexpire counter soon\n"))
+		if !strings.Contains(output, "line 2") || !strings.Contains(output, "invalid ttl") {
+			t.Errorf("Expected invalid ttl error, got: %s", output)
+		}
+
+		// Values are validated like with set
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+		RunKVFailure(t, "batch", writeBatchFile(t, "set counter 4\nset port eighty\n"))
+
+		if output := RunKVSuccess(t, "get", "counter"); output != "1" {
+			t.Errorf("Expected no changes to be applied, got %q", output)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "a", "1")
+		RunKVSuccess(t, "set", "b", "2")
+		RunKVSuccess(t, "set", "secret", "s3cr3t")
+
+		output := RunKVSuccess(t, "batch", writeBatchFile(t, "set a 10\ndelete b\nset c 3\nexpire a 1h\nlock secret\n"), "--dry-run")
+
+		for _, expected := range []string{"~ a = 1 -> 10", "~ a expires at", "- b", "+ c = 3", "~ secret = s3cr3t -> [Locked]"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in dry run output, got: %s", expected, output)
+			}
+		}
+
+		// Nothing was applied
+		if output := RunKVSuccess(t, "get", "a"); output != "1" {
+			t.Errorf("Expected a to be unchanged, got %q", output)
+		}
+
+		RunKVSuccess(t, "get", "b")
+		RunKVFailure(t, "get", "c")
+		RunKVSuccess(t, "get", "secret")
+	})
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupFakeClipboard puts a fake xclip on PATH that stores the clipboard in a file, and returns that file's path
+func setupFakeClipboard(t *testing.T) string {
+	t.Helper()
+
+	binDir := t.TempDir()
+	clipFile := filepath.Join(t.TempDir(), "clipboard")
+
+	script := "#!/bin/sh\ncase \"$*\" in\n  *-o*) cat \"$CLIP_FILE\" 2>/dev/null ;;\n  *) cat > \"$CLIP_FILE\" ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(binDir, "xclip"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("CLIP_FILE", clipFile)
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	return clipFile
+}
+
+func readClipboard(t *testing.T, clipFile string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(clipFile)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+// waitForClipboard polls the clipboard file until it holds expected or the timeout passes
+func waitForClipboard(t *testing.T, clipFile string, expected string, timeout time.Duration) string {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		content := readClipboard(t, clipFile)
+		if content == expected || time.Now().After(deadline) {
+			return content
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestClipboard(t *testing.T) {
+	t.Run("copy and clear", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "clipboard: xclip\n")
+		clipFile := setupFakeClipboard(t)
+
+		RunKVSuccess(t, "set", "token", "secret-value")
+
+		output := RunKVSuccess(t, "get", "token", "--clip", "--clear-after", "1s")
+		if strings.Contains(output, "secret-value") {
+			t.Errorf("Value should not be printed with --clip, got: %s", output)
+		}
+
+		if content := readClipboard(t, clipFile); content != "secret-value" {
+			t.Errorf("Expected clipboard to hold the value, got %q", content)
+		}
+
+		if content := waitForClipboard(t, clipFile, "", 5*time.Second); content != "" {
+			t.Errorf("Expected clipboard to be cleared, got %q", content)
+		}
+	})
+
+	t.Run("does not clear replaced content", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "clipboard: xclip\n")
+		clipFile := setupFakeClipboard(t)
+
+		RunKVSuccess(t, "set", "token", "secret-value")
+		RunKVSuccess(t, "get", "token", "--clip", "--clear-after", "1s")
+
+		if err := os.WriteFile(clipFile, []byte("other"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(2500 * time.Millisecond)
+
+		if content := readClipboard(t, clipFile); content != "other" {
+			t.Errorf("Expected replaced clipboard content to be kept, got %q", content)
+		}
+	})
+
+	t.Run("keep value with zero duration", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "")
+		clipFile := setupFakeClipboard(t)
+
+		RunKVSuccess(t, "set", "token", "secret-value")
+		RunKVSuccess(t, "get", "token", "--clip", "--clear-after", "0")
+
+		time.Sleep(1500 * time.Millisecond)
+
+		if content := readClipboard(t, clipFile); content != "secret-value" {
+			t.Errorf("Expected clipboard to keep the value, got %q", content)
+		}
+	})
+
+	t.Run("locked value", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "clipboard: xclip\n")
+		clipFile := setupFakeClipboard(t)
+
+		RunKVSuccess(t, "set", "token", "secret-value", "--password=pass")
+
+		output := RunKVSuccess(t, "get", "token", "--password=pass", "--clip", "--clear-after", "0")
+		if strings.Contains(output, "secret-value") {
+			t.Errorf("Value should not be printed with --clip, got: %s", output)
+		}
+
+		if content := readClipboard(t, clipFile); content != "secret-value" {
+			t.Errorf("Expected clipboard to hold the decrypted value, got %q", content)
+		}
+	})
+
+	t.Run("no clipboard available", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "clipboard: xclip\n")
+		t.Setenv("PATH", t.TempDir())
+
+		RunKVSuccess(t, "set", "token", "secret-value")
+		RunKVFailure(t, "get", "token", "--clip")
+	})
+}
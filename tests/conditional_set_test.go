@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// conflictExitCode is the exit status of conditional writes whose condition does not hold
+const conflictExitCode = 3
+
+// RunKVConflict runs kv and fails the test unless it exits with the conflict status
+func RunKVConflict(t *testing.T, args ...string) string {
+	t.Helper()
+
+	output, err := RunKV(t, args...)
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != conflictExitCode {
+		t.Fatalf("Command should have exited with status %d: kv %v\nError: %v\nOutput: %s", conflictExitCode, args, err, output)
+	}
+
+	return output
+}
+
+var versionPattern = regexp.MustCompile(`version: (\d+)`)
+
+// getVersion returns the version of key as printed by get --meta
+func getVersion(t *testing.T, key string) string {
+	t.Helper()
+
+	match := versionPattern.FindStringSubmatch(RunKVSuccess(t, "get", key, "--meta"))
+	if match == nil {
+		t.Fatalf("No version in metadata of key %q", key)
+	}
+
+	return match[1]
+}
+
+func TestConditionalSet(t *testing.T) {
+	t.Run("if-version", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "config", "v1", "--if-version", "0")
+		RunKVConflict(t, "set", "config", "again", "--if-version", "0")
+
+		version := getVersion(t, "config")
+		RunKVSuccess(t, "set", "config", "v2", "--if-version", version)
+
+		// The version changed with the value
+		output := RunKVConflict(t, "set", "config", "v3", "--if-version", version)
+		if !strings.Contains(output, "not "+version) {
+			t.Errorf("Expected version mismatch, got: %s", output)
+		}
+
+		if value := RunKVSuccess(t, "get", "config"); value != "v2" {
+			t.Errorf("Expected v2, got %q", value)
+		}
+
+		if output := RunKVSuccess(t, "list", "-o", "json"); !strings.Contains(output, `"version": `+getVersion(t, "config")) {
+			t.Errorf("Expected version in list output, got: %s", output)
+		}
+	})
+
+	t.Run("if-value", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "state", "idle")
+		RunKVConflict(t, "set", "state", "running", "--if-value", "stopped")
+		RunKVSuccess(t, "set", "state", "running", "--if-value", "idle")
+		RunKVConflict(t, "set", "missing", "running", "--if-value", "idle")
+
+		// Locked values are compared after decryption
+		RunKVSuccess(t, "set", "secret", "old", "--password=pass")
+		RunKVFailure(t, "set", "secret", "new", "--if-value", "old")
+		RunKVConflict(t, "set", "secret", "new", "--if-value", "other", "--password=pass")
+		RunKVSuccess(t, "set", "secret", "new", "--if-value", "old", "--password=pass")
+
+		if value := RunKVSuccess(t, "get", "secret", "--password=pass"); value != "new" {
+			t.Errorf("Expected new, got %q", value)
+		}
+	})
+
+	t.Run("if-absent and if-exists", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVConflict(t, "set", "owner", "alice", "--if-exists")
+		RunKVSuccess(t, "set", "owner", "alice", "--if-absent")
+		RunKVConflict(t, "set", "owner", "bob", "--if-absent")
+		RunKVSuccess(t, "set", "owner", "bob", "--if-exists")
+
+		// Deleted keys are absent
+		RunKVSuccess(t, "delete", "owner")
+		RunKVSuccess(t, "set", "owner", "carol", "--if-absent")
+
+		RunKVFailure(t, "set", "owner", "dave", "--if-absent", "--if-exists")
+	})
+
+	t.Run("conditional path updates", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "app.config", `{"port": 8080}`)
+		version := getVersion(t, "app.config")
+
+		RunKVSuccess(t, "set", "app.config", "--path", ".port", "9090", "--if-version", version)
+		RunKVConflict(t, "set", "app.config", "--path", ".port", "7070", "--if-version", version)
+	})
+
+	t.Run("only one concurrent writer wins", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "leader", "none")
+		version := getVersion(t, "leader")
+
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() {
+				if _, err := RunKV(t, "set", "leader", "me", "--if-version", version); err == nil {
+					wins.Add(1)
+				}
+			})
+		}
+
+		wg.Wait()
+
+		if wins.Load() != 1 {
+			t.Errorf("Expected exactly one writer to win, got %d", wins.Load())
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	t.Run("incr and decr", func(t *testing.T) {
+		SetupTestDB(t)
+
+		if output := RunKVSuccess(t, "incr", "counter"); output != "1" {
+			t.Errorf("Expected counter to start from 0, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "incr", "counter", "--by", "10"); output != "11" {
+			t.Errorf("Expected 11, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "decr", "counter", "--by", "20"); output != "-9" {
+			t.Errorf("Expected -9, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "counter"); output != "-9" {
+			t.Errorf("Expected stored value -9, got %q", output)
+		}
+
+		output := RunKVSuccess(t, "history", "list", "counter", "-o", "json")
+		if strings.Count(output, `"value"`) != 3 {
+			t.Errorf("Expected 3 versions in history, got: %s", output)
+		}
+	})
+
+	t.Run("non-integer and locked values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "name", "kv")
+		output := RunKVFailure(t, "incr", "name")
+		if !strings.Contains(output, "not an integer") {
+			t.Errorf("Expected integer error, got: %s", output)
+		}
+
+		RunKVSuccess(t, "set", "secret", "1", "--password=pass")
+		RunKVFailure(t, "incr", "secret")
+		RunKVFailure(t, "append", "secret", "2")
+
+		RunKVSuccess(t, "set", "max", "9223372036854775807")
+		RunKVFailure(t, "incr", "max")
+	})
+
+	t.Run("type and expiry are kept", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int", "--expires-after", "1h")
+		RunKVSuccess(t, "incr", "port")
+
+		output := RunKVSuccess(t, "list", "-o", "json")
+		if !strings.Contains(output, `"value": 8081`) || !strings.Contains(output, `"expiresAt"`) {
+			t.Errorf("Expected typed value with expiry, got: %s", output)
+		}
+
+		RunKVSuccess(t, "set", "flag", "true", "--type", "bool")
+		RunKVFailure(t, "append", "flag", "x")
+	})
+
+	t.Run("links are followed", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "builds", "41")
+		RunKVSuccess(t, "link", "current-build", "builds")
+
+		if output := RunKVSuccess(t, "incr", "current-build"); output != "42" {
+			t.Errorf("Expected 42, got %q", output)
+		}
+	})
+
+	t.Run("concurrent increments are not lost", func(t *testing.T) {
+		SetupTestDB(t)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				for range 5 {
+					RunKVSuccess(t, "incr", "counter")
+				}
+			})
+		}
+
+		wg.Wait()
+
+		if output := RunKVSuccess(t, "get", "counter"); output != "50" {
+			t.Errorf("Expected 50, got %q", output)
+		}
+	})
+}
+
+func TestAppend(t *testing.T) {
+	t.Run("append text", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "append", "greeting", "hello")
+		if output := RunKVSuccess(t, "append", "greeting", ", world"); output != "hello, world" {
+			t.Errorf("Expected %q, got %q", "hello, world", output)
+		}
+	})
+
+	t.Run("append lines from stdin", func(t *testing.T) {
+		SetupTestDB(t)
+
+		for i := range 3 {
+			cmd := RunKVCommand(t, "append", "log", "--newline")
+			cmd.Stdin = strings.NewReader(fmt.Sprintf("line %d\n", i))
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("append failed: %v: %s", err, output)
+			}
+		}
+
+		if output := RunKVSuccess(t, "get", "log"); output != "line 0\nline 1\nline 2" {
+			t.Errorf("Expected 3 lines, got %q", output)
+		}
+	})
+}
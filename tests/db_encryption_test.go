@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+func TestDBEncryption(t *testing.T) {
+	t.Run("encrypt and use with password", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret-name", "secret-value")
+
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+
+		if _, err := os.Stat(common.GetDBPath()); !os.IsNotExist(err) {
+			t.Error("Plain database file should be removed")
+		}
+
+		content, err := os.ReadFile(common.GetEncryptedDBPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), "secret-name") || strings.Contains(string(content), "secret-value") {
+			t.Error("Encrypted database file contains plain text")
+		}
+
+		// Locked without the password
+		output := RunKVFailure(t, "get", "secret-name")
+		if !strings.Contains(output, "kv db unlock") {
+			t.Errorf("Expected locked database error, got: %s", output)
+		}
+
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+
+		output = RunKVSuccess(t, "get", "secret-name")
+		if output != "secret-value" {
+			t.Errorf("Expected 'secret-value', got: %s", output)
+		}
+
+		// Writes are persisted
+		RunKVSuccess(t, "set", "other", "value")
+		output = RunKVSuccess(t, "get", "other")
+		if output != "value" {
+			t.Errorf("Expected 'value', got: %s", output)
+		}
+
+		t.Setenv("KV_DB_PASSWORD", "wrong")
+		output = RunKVFailure(t, "get", "other")
+		if !strings.Contains(output, "wrong database password") {
+			t.Errorf("Expected wrong password error, got: %s", output)
+		}
+	})
+
+	t.Run("not locked while waiting for a password", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "plain", "value")
+		RunKVSuccess(t, "set", "secret", "hidden", "--password=pass")
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var output bytes.Buffer
+		waiting := RunKVCommand(t, "get", "secret", "--password-fd", "3")
+		waiting.ExtraFiles = []*os.File{reader}
+		waiting.Stdout, waiting.Stderr = &output, &output
+		if err := waiting.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Killing the waiting command releases the database if it's kept locked
+		watchdog := time.AfterFunc(5*time.Second, func() { _ = waiting.Process.Kill() })
+		defer watchdog.Stop()
+
+		// Give it time to open the database and block reading the password
+		time.Sleep(500 * time.Millisecond)
+
+		start := time.Now()
+		RunKVSuccess(t, "set", "plain", "changed")
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("Expected set not to wait for the other command, took %s", elapsed)
+		}
+
+		_, _ = writer.WriteString("pass\n")
+		_ = writer.Close()
+
+		if err := waiting.Wait(); err != nil {
+			t.Fatalf("Waiting command failed: %v\nOutput: %s", err, output.String())
+		}
+
+		if got := strings.TrimSpace(output.String()); got != "hidden" {
+			t.Errorf("Expected 'hidden', got: %s", got)
+		}
+
+		if got := RunKVSuccess(t, "get", "plain"); got != "changed" {
+			t.Errorf("Expected 'changed', got: %s", got)
+		}
+	})
+
+	t.Run("unlock for session", func(t *testing.T) {
+		SetupTestDB(t)
+		setupAgent(t)
+		RunKVSuccess(t, "set", "key", "value")
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+
+		RunKVFailure(t, "db", "unlock", "--password=wrong")
+		RunKVSuccess(t, "db", "unlock", "--password=dbpass")
+
+		output := RunKVSuccess(t, "get", "key")
+		if output != "value" {
+			t.Errorf("Expected 'value', got: %s", output)
+		}
+
+		RunKVSuccess(t, "db", "lock")
+		RunKVFailure(t, "get", "key")
+	})
+
+	t.Run("decrypt", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "key", "value")
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+		RunKVSuccess(t, "db", "decrypt")
+
+		if _, err := os.Stat(common.GetEncryptedDBPath()); !os.IsNotExist(err) {
+			t.Error("Encrypted database file should be removed")
+		}
+
+		t.Setenv("KV_DB_PASSWORD", "")
+		output := RunKVSuccess(t, "get", "key")
+		if output != "value" {
+			t.Errorf("Expected 'value', got: %s", output)
+		}
+	})
+
+	t.Run("backup and restore", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "key", "original")
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+
+		backupPath := filepath.Join(t.TempDir(), "backup.db")
+		RunKVSuccess(t, "db", "backup", "--path", backupPath)
+
+		content, err := os.ReadFile(backupPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), "original") {
+			t.Error("Backup of encrypted database contains plain text")
+		}
+
+		RunKVSuccess(t, "set", "key", "changed")
+		RunKVSuccess(t, "db", "restore", "--path", backupPath)
+
+		output := RunKVSuccess(t, "get", "key")
+		if output != "original" {
+			t.Errorf("Expected 'original', got: %s", output)
+		}
+	})
+
+	t.Run("restore plain backup keeps encryption", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "key", "plain")
+
+		backupPath := filepath.Join(t.TempDir(), "backup.db")
+		RunKVSuccess(t, "db", "backup", "--path", backupPath)
+
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+
+		RunKVSuccess(t, "set", "key", "changed")
+		RunKVSuccess(t, "db", "restore", "--path", backupPath)
+
+		if _, err := os.Stat(common.GetDBPath()); !os.IsNotExist(err) {
+			t.Error("Plain database file should be removed")
+		}
+
+		output := RunKVSuccess(t, "get", "key")
+		if output != "plain" {
+			t.Errorf("Expected 'plain', got: %s", output)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGen(t *testing.T) {
+	t.Run("generate default value", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "gen", "token")
+
+		value := RunKVSuccess(t, "get", "token")
+		if !regexp.MustCompile(`^[A-Za-z0-9]{32}$`).MatchString(value) {
+			t.Errorf("Expected 32 alphanumeric characters, got %q", value)
+		}
+	})
+
+	t.Run("generate with length and charset", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "gen", "hex-key", "--length", "64", "--charset", "hex")
+		if value := RunKVSuccess(t, "get", "hex-key"); !regexp.MustCompile(`^[0-9a-f]{64}$`).MatchString(value) {
+			t.Errorf("Expected 64 hex characters, got %q", value)
+		}
+
+		RunKVSuccess(t, "gen", "b64-key", "--length", "20", "--charset", "base64")
+		if value := RunKVSuccess(t, "get", "b64-key"); !regexp.MustCompile(`^[A-Za-z0-9_-]{20}$`).MatchString(value) {
+			t.Errorf("Expected 20 base64 characters, got %q", value)
+		}
+
+		RunKVSuccess(t, "gen", "passphrase", "--charset", "words")
+		if value := RunKVSuccess(t, "get", "passphrase"); len(strings.Split(value, "-")) != 6 {
+			t.Errorf("Expected 6 words, got %q", value)
+		}
+	})
+
+	t.Run("generate locked and hidden", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "gen", "locked", "--lock", "--password=pass")
+		RunKVFailure(t, "get", "locked")
+		if value := RunKVSuccess(t, "get", "locked", "--password=pass"); len(value) != 32 {
+			t.Errorf("Expected 32 character value, got %q", value)
+		}
+
+		RunKVSuccess(t, "gen", "hidden", "--hidden")
+		output := RunKVSuccess(t, "list")
+		if !strings.Contains(output, "[Hidden]") || !strings.Contains(output, "[Locked]") {
+			t.Errorf("Expected hidden and locked keys in list, got: %s", output)
+		}
+	})
+
+	t.Run("existing key fails", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "token", "value")
+		output := RunKVFailure(t, "gen", "token")
+		if !strings.Contains(output, "kv rotate") {
+			t.Errorf("Expected hint to use rotate, got: %s", output)
+		}
+	})
+
+	t.Run("invalid options fail", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "gen", "token", "--charset", "emoji")
+		RunKVFailure(t, "gen", "token", "--length", "0")
+	})
+
+	t.Run("generated values are validated against schemas", func(t *testing.T) {
+		SetupTestDB(t)
+		setSchema(t, "ports.", `{"type": "integer"}`)
+
+		RunKVFailure(t, "gen", "ports.http")
+		RunKVFailure(t, "get", "ports.http")
+	})
+}
+
+func TestRotate(t *testing.T) {
+	t.Run("rotate keeps old value in history", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "token", "old-value")
+		RunKVSuccess(t, "rotate", "token")
+
+		value := RunKVSuccess(t, "get", "token")
+		if value == "old-value" || len(value) != 32 {
+			t.Errorf("Expected a new 32 character value, got %q", value)
+		}
+
+		output := RunKVSuccess(t, "history", "list", "token")
+		if !strings.Contains(output, "old-value") {
+			t.Errorf("Expected old value in history, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "list", "-o", "json")
+		if !strings.Contains(output, `"rotatedAt"`) {
+			t.Errorf("Expected rotatedAt in JSON output, got: %s", output)
+		}
+	})
+
+	t.Run("rotate keeps hidden state", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "token", "old-value", "--hidden")
+		RunKVSuccess(t, "rotate", "token")
+
+		if output := RunKVSuccess(t, "list"); !strings.Contains(output, "[Hidden]") {
+			t.Errorf("Expected key to stay hidden, got: %s", output)
+		}
+	})
+
+	t.Run("rotate locked key", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "token", "old-value", "--password=pass")
+		RunKVFailure(t, "rotate", "token", "--password=wrong")
+		RunKVSuccess(t, "rotate", "token", "--password=pass", "--charset", "hex")
+
+		value := RunKVSuccess(t, "get", "token", "--password=pass")
+		if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(value) {
+			t.Errorf("Expected a new hex value, got %q", value)
+		}
+	})
+
+	t.Run("rotate missing key fails", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "rotate", "missing")
+	})
+
+	t.Run("rotated values are validated against the key type", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+		RunKVFailure(t, "rotate", "port")
+
+		if output := RunKVSuccess(t, "get", "port"); output != "8080" {
+			t.Errorf("Expected value to be kept, got %q", output)
+		}
+	})
+}
+
+func TestListStale(t *testing.T) {
+	SetupTestDB(t)
+
+	RunKVSuccess(t, "set", "plain", "value")
+	RunKVSuccess(t, "gen", "old-token")
+	RunKVSuccess(t, "gen", "new-token")
+	RunKVSuccess(t, "gen", "manual-token")
+
+	time.Sleep(2500 * time.Millisecond)
+	RunKVSuccess(t, "rotate", "new-token")
+
+	// Replacing a value by hand counts as a change, changing its expiry does not
+	RunKVSuccess(t, "set", "manual-token", "replaced")
+	RunKVSuccess(t, "expire", "old-token", "--after", "1h")
+
+	output := RunKVSuccess(t, "list", "--stale", "2s", "--no-values")
+	if !strings.Contains(output, "old-token") || !strings.Contains(output, "plain") {
+		t.Errorf("Expected stale keys in output, got: %s", output)
+	}
+
+	if strings.Contains(output, "new-token") || strings.Contains(output, "manual-token") {
+		t.Errorf("Recently rotated or changed keys should not be stale, got: %s", output)
+	}
+
+	output = RunKVSuccess(t, "list", "--stale", "90d")
+	if !strings.Contains(output, "No stale items") {
+		t.Errorf("Expected no stale items, got: %s", output)
+	}
+
+	RunKVFailure(t, "list", "--stale", "soon")
+}
@@ -4,6 +4,7 @@ package tests
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -25,7 +26,6 @@ func RunKV(t *testing.T, args ...string) (string, error) {
 	return strings.TrimSpace(string(output)), err
 }
 
-
 func RunKVSuccess(t *testing.T, args ...string) string {
 	t.Helper()
 	output, err := RunKV(t, args...)
@@ -44,6 +44,27 @@ func RunKVFailure(t *testing.T, args ...string) string {
 	return output
 }
 
+// RunKVWithPasswordFd runs kv with password readable from file descriptor 3, to be passed with --password-fd 3
+func RunKVWithPasswordFd(t *testing.T, password string, args ...string) (string, error) {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = writer.WriteString(password + "\n")
+	_ = writer.Close()
+
+	cmd := RunKVCommand(t, args...)
+	cmd.ExtraFiles = []*os.File{reader}
+
+	output, err := cmd.CombinedOutput()
+	_ = reader.Close()
+
+	return strings.TrimSpace(string(output)), err
+}
+
 // SetupTestDB creates a temporary database for testing and registers cleanup via t.Cleanup.
 func SetupTestDB(t *testing.T) {
 	t.Helper()
@@ -63,3 +84,20 @@ func SetupTestDB(t *testing.T) {
 		_ = os.RemoveAll(tmpDir)
 	})
 }
+
+// SetupTestConfig writes given YAML content as kv config for the duration of the test.
+func SetupTestConfig(t *testing.T, content string) {
+	t.Helper()
+
+	// Set XDG_CONFIG_HOME to use temp directory, same as SetupTestDB does for data
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	if err := os.MkdirAll(filepath.Join(configHome, "kv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(configHome, "kv", "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
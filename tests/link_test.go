@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	t.Run("get follows links", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.staging", "postgres://staging")
+		RunKVSuccess(t, "set", "db.prod", "postgres://prod")
+		RunKVSuccess(t, "link", "db.current", "db.staging")
+
+		if output := RunKVSuccess(t, "get", "db.current"); output != "postgres://staging" {
+			t.Errorf("Expected staging value, got %q", output)
+		}
+
+		RunKVSuccess(t, "link", "db.current", "db.prod")
+		if output := RunKVSuccess(t, "get", "db.current"); output != "postgres://prod" {
+			t.Errorf("Expected prod value, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "db.current", "--no-deref"); output != "db.prod" {
+			t.Errorf("Expected link target, got %q", output)
+		}
+	})
+
+	t.Run("list shows links", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.staging", "postgres://staging")
+		RunKVSuccess(t, "link", "db.current", "db.staging")
+
+		output := RunKVSuccess(t, "list")
+		if !strings.Contains(output, "db.current -> db.staging") {
+			t.Errorf("Expected link in list, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "list", "-o", "json")
+		if !strings.Contains(output, `"isLink": true`) {
+			t.Errorf("Expected isLink in JSON output, got: %s", output)
+		}
+	})
+
+	t.Run("chained links", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "value", "hello")
+		RunKVSuccess(t, "link", "first", "value")
+		RunKVSuccess(t, "link", "second", "first")
+
+		if output := RunKVSuccess(t, "get", "second"); output != "hello" {
+			t.Errorf("Expected chained value, got %q", output)
+		}
+	})
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "value", "hello")
+		RunKVSuccess(t, "link", "a", "value")
+		RunKVSuccess(t, "link", "b", "a")
+
+		if output := RunKVFailure(t, "link", "a", "b"); !strings.Contains(output, "cycle") {
+			t.Errorf("Expected cycle error, got: %s", output)
+		}
+
+		RunKVFailure(t, "link", "a", "a")
+	})
+
+	t.Run("dangling links", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "link", "alias", "missing")
+
+		RunKVSuccess(t, "set", "target", "value")
+		RunKVSuccess(t, "link", "alias", "target")
+		RunKVSuccess(t, "delete", "target")
+
+		if output := RunKVFailure(t, "get", "alias"); !strings.Contains(output, "missing key") {
+			t.Errorf("Expected dangling link error, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "alias", "--no-deref"); output != "target" {
+			t.Errorf("Expected link target, got %q", output)
+		}
+	})
+
+	t.Run("values are not replaced", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "existing", "value")
+		RunKVSuccess(t, "set", "target", "other")
+
+		if output := RunKVFailure(t, "link", "existing", "target"); !strings.Contains(output, "not a link") {
+			t.Errorf("Expected error for existing key, got: %s", output)
+		}
+	})
+
+	t.Run("set replaces a link", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "target", "value")
+		RunKVSuccess(t, "link", "alias", "target")
+		RunKVSuccess(t, "set", "alias", "target")
+
+		if output := RunKVSuccess(t, "get", "alias"); output != "target" {
+			t.Errorf("Expected plain value after set, got %q", output)
+		}
+	})
+
+	t.Run("revert and copy keep links", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "t1", "one")
+		RunKVSuccess(t, "set", "t2", "two")
+		RunKVSuccess(t, "link", "cur", "t1")
+		RunKVSuccess(t, "link", "cur", "t2")
+
+		RunKVSuccess(t, "history", "revert", "cur")
+		if output := RunKVSuccess(t, "get", "cur"); output != "one" {
+			t.Errorf("Expected reverted link to resolve to t1, got %q", output)
+		}
+
+		// A link replaced by set is restored as a link
+		RunKVSuccess(t, "set", "cur", "plain")
+		RunKVSuccess(t, "history", "revert", "cur")
+		if output := RunKVSuccess(t, "get", "cur", "--no-deref"); output != "t1" {
+			t.Errorf("Expected link to t1, got %q", output)
+		}
+
+		RunKVSuccess(t, "copy", "cur", "cur2")
+		if output := RunKVSuccess(t, "get", "cur2"); output != "one" {
+			t.Errorf("Expected copied link to resolve to t1, got %q", output)
+		}
+
+		RunKVSuccess(t, "set", "t1", "changed")
+		if output := RunKVSuccess(t, "get", "cur2"); output != "changed" {
+			t.Errorf("Expected copied link to follow its target, got %q", output)
+		}
+	})
+
+	t.Run("locked targets", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "secret", "s3cret", "--password=pass")
+		RunKVSuccess(t, "link", "alias", "secret")
+
+		if output := RunKVSuccess(t, "get", "alias", "--password=pass"); output != "s3cret" {
+			t.Errorf("Expected decrypted value, got %q", output)
+		}
+
+		RunKVFailure(t, "lock", "alias", "--password=pass")
+
+		// Matched links are skipped when locking by prefix
+		RunKVSuccess(t, "set", "plain", "value")
+		RunKVSuccess(t, "link", "plain-alias", "plain")
+		RunKVSuccess(t, "lock", "plain", "--prefix", "--password=pass")
+		if output := RunKVSuccess(t, "get", "plain-alias", "--no-deref"); output != "plain" {
+			t.Errorf("Expected link to be kept as is, got %q", output)
+		}
+	})
+}
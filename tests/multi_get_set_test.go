@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMultiGet(t *testing.T) {
+	t.Run("json output", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.host", "localhost")
+		RunKVSuccess(t, "set", "db.port", "5432", "--type", "int")
+		RunKVSuccess(t, "set", "db.password", "secret", "--password=pass")
+
+		output := RunKVSuccess(t, "get", "db.host", "db.port", "db.password", "--password=pass")
+
+		var values map[string]any
+		if err := json.Unmarshal([]byte(output), &values); err != nil {
+			t.Fatalf("Invalid JSON output: %v: %s", err, output)
+		}
+
+		expected := map[string]any{"db.host": "localhost", "db.port": float64(5432), "db.password": "secret"}
+		for key, value := range expected {
+			if values[key] != value {
+				t.Errorf("Expected %s to be %#v, got %#v", key, value, values[key])
+			}
+		}
+	})
+
+	t.Run("yaml and env output", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.host", "localhost")
+		RunKVSuccess(t, "set", "app-motd", "it's ok")
+
+		if output := RunKVSuccess(t, "get", "db.host", "app-motd", "-o", "yaml"); output != "app-motd: it's ok\ndb.host: localhost" {
+			t.Errorf("Unexpected YAML output: %q", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "db.host", "app-motd", "-o", "env"); output != `DB_HOST='localhost'`+"\n"+`APP_MOTD='it'\''s ok'` {
+			t.Errorf("Unexpected env output: %q", output)
+		}
+
+		// A single key is printed as a map with --output
+		if output := RunKVSuccess(t, "get", "db.host", "-o", "env"); output != "DB_HOST='localhost'" {
+			t.Errorf("Unexpected env output: %q", output)
+		}
+
+		// Colliding names fail before anything is printed
+		RunKVSuccess(t, "set", "db-host", "other")
+		output := RunKVFailure(t, "get", "app-motd", "db.host", "db-host", "-o", "env")
+		if !strings.Contains(output, "same variable name DB_HOST") || strings.Contains(output, "APP_MOTD=") {
+			t.Errorf("Expected collision error without output, got: %s", output)
+		}
+	})
+
+	t.Run("missing keys", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "present", "1")
+
+		output := RunKVSuccess(t, "get", "present", "missing")
+		if !strings.Contains(output, `"present": "1"`) || !strings.Contains(output, `Key "missing" does not exist`) {
+			t.Errorf("Expected value and missing key report, got: %s", output)
+		}
+
+		output = RunKVFailure(t, "get", "present", "missing", "--strict")
+		if !strings.Contains(output, "Missing keys: missing") || strings.Contains(output, `"present"`) {
+			t.Errorf("Expected failure without values, got: %s", output)
+		}
+	})
+
+	t.Run("single key options", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "a", "1")
+		RunKVSuccess(t, "set", "b", "2")
+
+		RunKVFailure(t, "get", "a", "b", "--meta")
+		RunKVFailure(t, "get", "a", "-o", "json", "--clip")
+		RunKVFailure(t, "get", "a", "b", "-o", "xml")
+	})
+
+	t.Run("credentials are read once", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "a", "1", "--password=pass")
+		RunKVSuccess(t, "set", "b", "2", "--password=pass")
+		RunKVSuccess(t, "vault", "create", "team.", "--password=pass")
+		RunKVSuccess(t, "set", "team.x", "3", "--password=pass")
+		RunKVSuccess(t, "set", "team.y", "4", "--password=pass")
+
+		output, err := RunKVWithPasswordFd(t, "pass", "get", "a", "b", "team.x", "team.y", "--password-fd", "3")
+		if err != nil {
+			t.Fatalf("Command failed: %v\nOutput: %s", err, output)
+		}
+
+		var values map[string]any
+		if err := json.Unmarshal([]byte(output), &values); err != nil {
+			t.Fatalf("Invalid JSON output: %v: %s", err, output)
+		}
+
+		for key, expected := range map[string]string{"a": "1", "b": "2", "team.x": "3", "team.y": "4"} {
+			if values[key] != expected {
+				t.Errorf("Expected %s to be %s, got %v", key, expected, values[key])
+			}
+		}
+	})
+}
+
+func TestMultiSet(t *testing.T) {
+	t.Run("values are stored", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "mset", "db.host=localhost", "db.url=postgres://u@h/db?sslmode=require")
+
+		if output := RunKVSuccess(t, "get", "db.url"); output != "postgres://u@h/db?sslmode=require" {
+			t.Errorf("Expected value after the first =, got %q", output)
+		}
+
+		RunKVSuccess(t, "mset", "session.id=abc", "session.user=alice", "--expires-after", "1h")
+		output := RunKVSuccess(t, "list", "session.", "-o", "json")
+		if strings.Count(output, `"expiresAt"`) != 2 {
+			t.Errorf("Expected both values to expire, got: %s", output)
+		}
+	})
+
+	t.Run("values are locked with a password", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "mset", "a=1", "b=2", "--password=pass")
+		RunKVFailure(t, "get", "a")
+
+		if output := RunKVSuccess(t, "get", "a", "b", "-o", "env", "--password=pass"); output != "A='1'\nB='2'" {
+			t.Errorf("Unexpected output: %q", output)
+		}
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+
+		RunKVFailure(t, "mset", "host=example.com", "port=eighty")
+		RunKVFailure(t, "get", "host")
+
+		RunKVFailure(t, "mset", "host=example.com", "invalid")
+		RunKVFailure(t, "mset", "host=example.com", "=value")
+		RunKVFailure(t, "get", "host")
+	})
+}
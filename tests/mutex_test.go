@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMutex(t *testing.T) {
+	t.Run("acquire and release", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "mutex", "acquire", "deploy", "--owner", "job-a")
+
+		// Acquiring again renews the lease of the same owner
+		RunKVSuccess(t, "mutex", "acquire", "deploy", "--owner", "job-a")
+
+		output := RunKVConflict(t, "mutex", "acquire", "deploy", "--owner", "job-b")
+		if !strings.Contains(output, "held by job-a") {
+			t.Errorf("Expected holder in output, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "mutex", "list"); !strings.Contains(output, "deploy") || !strings.Contains(output, "job-a") {
+			t.Errorf("Expected held mutex in list, got: %s", output)
+		}
+
+		RunKVConflict(t, "mutex", "release", "deploy", "--owner", "job-b")
+		RunKVSuccess(t, "mutex", "release", "deploy", "--owner", "job-a")
+		RunKVFailure(t, "mutex", "release", "deploy", "--owner", "job-a")
+
+		RunKVSuccess(t, "mutex", "acquire", "deploy", "--owner", "job-b")
+		RunKVSuccess(t, "mutex", "release", "deploy", "--force")
+	})
+
+	t.Run("leases expire", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "mutex", "acquire", "deploy", "--owner", "job-a", "--ttl", "1s")
+		RunKVConflict(t, "mutex", "acquire", "deploy", "--owner", "job-b")
+
+		// Waits for the lease of job-a to expire
+		RunKVSuccess(t, "mutex", "acquire", "deploy", "--owner", "job-b", "--wait", "5s")
+	})
+
+	t.Run("run holds the mutex while the command runs", func(t *testing.T) {
+		SetupTestDB(t)
+
+		logPath := filepath.Join(t.TempDir(), "log")
+		script := `echo start >> "$1"; sleep 1; echo end >> "$1"`
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Go(func() {
+				RunKVSuccess(t, "mutex", "run", "job", "--ttl", "3s", "--wait", "10s", "--", "sh", "-c", script, "sh", logPath)
+			})
+		}
+
+		wg.Wait()
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != "start\nend\nstart\nend\n" {
+			t.Errorf("Expected commands not to overlap, got %q", content)
+		}
+
+		if output := RunKVSuccess(t, "mutex", "list"); !strings.Contains(output, "No held mutexes") {
+			t.Errorf("Expected mutex to be released, got: %s", output)
+		}
+	})
+
+	t.Run("run renews the lease", func(t *testing.T) {
+		SetupTestDB(t)
+
+		cmd := RunKVCommand(t, "mutex", "run", "job", "--ttl", "1s", "--", "sleep", "3")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(2 * time.Second)
+		RunKVConflict(t, "mutex", "acquire", "job", "--owner", "other")
+
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("run exits with the status of the command", func(t *testing.T) {
+		SetupTestDB(t)
+
+		_, err := RunKV(t, "mutex", "run", "job", "--", "sh", "-c", "exit 7")
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+			t.Errorf("Expected exit status 7, got %v", err)
+		}
+
+		RunKVSuccess(t, "mutex", "acquire", "job", "--owner", "job-a")
+		RunKVConflict(t, "mutex", "run", "job", "--", "true")
+	})
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// totpSeed is the base32 form of the RFC 6238 SHA1 test secret "12345678901234567890"
+const totpSeed = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// expectedTOTPCodes returns the 6 digit codes valid around now, to avoid failing on a period boundary
+func expectedTOTPCodes(t *testing.T) []string {
+	t.Helper()
+
+	secret, err := base32.StdEncoding.DecodeString(totpSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []string
+	for _, at := range []time.Time{time.Now().Add(-2 * time.Second), time.Now().Add(2 * time.Second)} {
+		mac := hmac.New(sha1.New, secret)
+		_ = binary.Write(mac, binary.BigEndian, uint64(at.Unix()/30))
+		sum := mac.Sum(nil)
+
+		offset := sum[len(sum)-1] & 0x0f
+		code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+		codes = append(codes, fmt.Sprintf("%06d", code%1_000_000))
+	}
+
+	return codes
+}
+
+// otpCode extracts the code line from combined otp output
+func otpCode(output string) string {
+	return strings.TrimSpace(strings.Split(output, "\n")[0])
+}
+
+func TestOTP(t *testing.T) {
+	t.Run("base32 seed", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "ci-bot.2fa", "--totp", strings.ToLower(totpSeed))
+
+		output := RunKVSuccess(t, "otp", "ci-bot.2fa")
+		expected := expectedTOTPCodes(t)
+
+		if code := otpCode(output); code != expected[0] && code != expected[1] {
+			t.Errorf("Expected one of %v, got %q", expected, code)
+		}
+
+		if !strings.Contains(output, "Valid for") {
+			t.Errorf("Expected remaining time in output, got: %s", output)
+		}
+
+		// Seeds are stored as otpauth URIs
+		if value := RunKVSuccess(t, "get", "ci-bot.2fa"); !strings.HasPrefix(value, "otpauth://totp/") {
+			t.Errorf("Expected otpauth URI, got %q", value)
+		}
+	})
+
+	t.Run("otpauth uri with options", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "bot", "--totp", "otpauth://totp/CI:bot?secret="+totpSeed+"&digits=8&issuer=CI")
+
+		if code := otpCode(RunKVSuccess(t, "otp", "bot")); len(code) != 8 {
+			t.Errorf("Expected 8 digit code, got %q", code)
+		}
+	})
+
+	t.Run("locked seed", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "bot", "--totp", totpSeed, "--password=pass")
+		RunKVFailure(t, "otp", "bot")
+		RunKVFailure(t, "otp", "bot", "--password=wrong")
+
+		output := RunKVSuccess(t, "otp", "bot", "--password=pass")
+		expected := expectedTOTPCodes(t)
+		if code := otpCode(output); code != expected[0] && code != expected[1] {
+			t.Errorf("Expected one of %v, got %q", expected, code)
+		}
+	})
+
+	t.Run("invalid seeds fail", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "set", "bot", "--totp", "not a seed!")
+		RunKVFailure(t, "set", "bot", "--totp", "otpauth://hotp/bot?secret="+totpSeed)
+	})
+
+	t.Run("non totp key fails", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "plain", "value")
+		output := RunKVFailure(t, "otp", "plain")
+		if !strings.Contains(output, "TOTP") {
+			t.Errorf("Expected TOTP error, got: %s", output)
+		}
+	})
+}
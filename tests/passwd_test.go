@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswdCommand(t *testing.T) {
+	t.Run("changes password in place", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=old")
+
+		output := RunKVSuccess(t, "passwd", "secret", "--password=old", "--new-password=new")
+		if !strings.Contains(output, "Changed password of 1 key(s)") {
+			t.Errorf("Expected 1 changed key, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "secret", "--password=new")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		RunKVFailure(t, "get", "secret", "--password=old")
+
+		// No plain text or extra entries in history
+		output = RunKVSuccess(t, "history", "list", "secret", "--output", "json")
+		if strings.Count(output, "timestamp") != 1 || strings.Contains(output, "data") {
+			t.Errorf("Expected a single locked history entry, got: %s", output)
+		}
+	})
+
+	t.Run("wrong password changes nothing", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "a", "data1", "--password=old")
+		RunKVSuccess(t, "set", "b", "data2", "--password=other")
+
+		output := RunKVFailure(t, "passwd", "--all", "--password=old", "--new-password=new")
+		if !strings.Contains(output, `Wrong password for key "b"`) {
+			t.Errorf("Expected wrong password error, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "a", "--password=old")
+		if output != "data1" {
+			t.Errorf("Expected 'a' to keep old password, got: %s", output)
+		}
+	})
+
+	t.Run("prefix skips plain keys", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "app/token", "data1", "--password=old")
+		RunKVSuccess(t, "set", "app/name", "plain")
+
+		output := RunKVSuccess(t, "passwd", "app/", "--prefix", "--password=old", "--new-password=new")
+		if !strings.Contains(output, "Changed password of 1 key(s)") {
+			t.Errorf("Expected 1 changed key, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "app/name")
+		if output != "plain" {
+			t.Errorf("Expected plain key untouched, got: %s", output)
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "v1", "--password=old")
+		RunKVSuccess(t, "set", "secret", "v2", "--password=other")
+		RunKVSuccess(t, "set", "secret", "v3", "--password=old")
+
+		output := RunKVSuccess(t, "passwd", "secret", "--history", "--password=old", "--new-password=new")
+		if !strings.Contains(output, "Re-encrypted 1 history record(s)") {
+			t.Errorf("Expected 1 re-encrypted history record, got: %s", output)
+		}
+
+		// Entries locked with another password are left as they are
+		RunKVSuccess(t, "history", "revert", "secret", "--steps", "1")
+		output = RunKVSuccess(t, "get", "secret", "--password=other")
+		if output != "v2" {
+			t.Errorf("Expected 'v2' with other password, got: %s", output)
+		}
+
+		RunKVSuccess(t, "history", "revert", "secret", "--steps", "3")
+		output = RunKVSuccess(t, "get", "secret", "--password=new")
+		if output != "v1" {
+			t.Errorf("Expected 'v1' with new password, got: %s", output)
+		}
+	})
+
+	t.Run("plain key fails", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "plain", "data")
+
+		output := RunKVFailure(t, "passwd", "plain", "--password=old", "--new-password=new")
+		if !strings.Contains(output, "not locked") {
+			t.Errorf("Expected 'not locked' error, got: %s", output)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPasswordSources(t *testing.T) {
+	t.Run("password from environment", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+
+		t.Setenv("KV_PASSWORD", "pass")
+
+		output := RunKVSuccess(t, "get", "secret")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		// Plain keys are not affected
+		RunKVSuccess(t, "set", "plain", "value")
+		output = RunKVSuccess(t, "get", "plain")
+		if output != "value" {
+			t.Errorf("Expected 'value', got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "list", "plain")
+		if strings.Contains(output, "[Locked]") {
+			t.Error("Environment password should not lock values on set")
+		}
+
+		// Explicit flag takes precedence
+		output = RunKVFailure(t, "get", "secret", "--password=wrong")
+		if !strings.Contains(output, "Wrong password") {
+			t.Errorf("Expected 'Wrong password' error, got: %s", output)
+		}
+	})
+
+	t.Run("password from file", func(t *testing.T) {
+		SetupTestDB(t)
+
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		if err := os.WriteFile(passwordFile, []byte("pass\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		RunKVSuccess(t, "set", "secret", "data", "--password-file", passwordFile)
+
+		output := RunKVSuccess(t, "list", "secret")
+		if !strings.Contains(output, "[Locked]") {
+			t.Error("Key should be locked")
+		}
+
+		output = RunKVSuccess(t, "get", "secret", "--password=pass")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		RunKVSuccess(t, "unlock", "secret", "--password-file", passwordFile)
+		RunKVSuccess(t, "lock", "secret", "--password-file", passwordFile)
+
+		output = RunKVSuccess(t, "get", "secret", "--password-file", passwordFile)
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		output = RunKVFailure(t, "get", "secret", "--password-file", passwordFile+".missing")
+		if !strings.Contains(output, "Could not read password file") {
+			t.Errorf("Expected password file error, got: %s", output)
+		}
+	})
+
+	t.Run("password from file descriptor", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = writer.WriteString("pass\n")
+		_ = writer.Close()
+
+		cmd := RunKVCommand(t, "get", "secret", "--password-fd", "3")
+		cmd.ExtraFiles = []*os.File{reader}
+
+		output, err := cmd.CombinedOutput()
+		_ = reader.Close()
+		if err != nil {
+			t.Fatalf("Command failed: %v\nOutput: %s", err, output)
+		}
+
+		if strings.TrimSpace(string(output)) != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+	})
+
+	t.Run("password from configured command", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data", "--password=pass")
+
+		SetupTestConfig(t, "password-command: echo pass\n")
+
+		output := RunKVSuccess(t, "get", "secret")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+	})
+
+	t.Run("password flags are mutually exclusive", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVFailure(t, "get", "secret", "--password=pass", "--password-fd", "0")
+	})
+}
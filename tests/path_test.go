@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	t.Run("get and set inside JSON values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "app.config", `{"server": {"port": 8080, "hosts": ["a", "b"]}, "debug": true}`)
+
+		if output := RunKVSuccess(t, "get", "app.config", "--path", ".server.port"); output != "8080" {
+			t.Errorf("Expected 8080, got %q", output)
+		}
+
+		if output := RunKVSuccess(t, "get", "app.config", "--path", ".server.hosts[1]"); output != "b" {
+			t.Errorf("Expected b, got %q", output)
+		}
+
+		RunKVSuccess(t, "set", "app.config", "--path", ".server.port", "9090")
+		RunKVSuccess(t, "set", "app.config", "--path", ".server.name", "api")
+
+		expected := `{"server":{"port":9090,"hosts":["a","b"],"name":"api"},"debug":true}`
+		if output := RunKVSuccess(t, "get", "app.config"); output != expected {
+			t.Errorf("Expected %s, got %s", expected, output)
+		}
+
+		// Each patch is a new version of the key
+		output := RunKVSuccess(t, "history", "list", "app.config", "-o", "json")
+		if strings.Count(output, `"value"`) != 3 {
+			t.Errorf("Expected 3 versions in history, got: %s", output)
+		}
+
+		RunKVFailure(t, "get", "app.config", "--path", ".server.missing")
+		RunKVFailure(t, "set", "app.config", "--path", ".debug.value", "1")
+		RunKVFailure(t, "get", "app.config", "--path", "server")
+	})
+
+	t.Run("get and set inside YAML values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "app.yaml", "server:\n  port: 8080\n  host: localhost\ndebug: true")
+		RunKVSuccess(t, "set", "app.yaml", "--path", ".server.port", "9090")
+
+		expected := "server:\n  port: 9090\n  host: localhost\ndebug: true"
+		if output := RunKVSuccess(t, "get", "app.yaml"); output != expected {
+			t.Errorf("Expected %q, got %q", expected, output)
+		}
+
+		if output := RunKVSuccess(t, "get", "app.yaml", "--path", ".server"); output != "port: 9090\nhost: localhost" {
+			t.Errorf("Expected server mapping, got %q", output)
+		}
+
+		RunKVSuccess(t, "set", "commented.yaml", "# app config\nserver:\n  port: 8080 # http port\n")
+		RunKVSuccess(t, "set", "commented.yaml", "--path", ".server.port", "9090")
+
+		expected = "# app config\nserver:\n  port: 9090 # http port"
+		if output := RunKVSuccess(t, "get", "commented.yaml"); output != expected {
+			t.Errorf("Expected comments to be kept, got %q", output)
+		}
+	})
+
+	t.Run("plain values cannot be patched", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "plain", "some text")
+		output := RunKVFailure(t, "set", "plain", "--path", ".field", "1")
+		if !strings.Contains(output, "not a JSON or YAML") {
+			t.Errorf("Expected document error, got: %s", output)
+		}
+
+		RunKVFailure(t, "set", "missing", "--path", ".field", "1")
+	})
+
+	t.Run("locked values stay locked", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "secret.config", `{"token": "old"}`, "--password=pass")
+		RunKVFailure(t, "set", "secret.config", "--path", ".token", "new", "--password=wrong")
+		RunKVSuccess(t, "set", "secret.config", "--path", ".token", "new", "--password=pass")
+
+		if output := RunKVSuccess(t, "get", "secret.config", "--path", ".token", "--password=pass"); output != "new" {
+			t.Errorf("Expected new, got %q", output)
+		}
+
+		RunKVFailure(t, "get", "secret.config")
+	})
+
+	t.Run("patched values are validated", func(t *testing.T) {
+		SetupTestDB(t)
+
+		setSchema(t, "svc.", appSchema)
+
+		RunKVSuccess(t, "set", "svc.config", `{"port": 8080}`, "--type", "json")
+		RunKVFailure(t, "set", "svc.config", "--path", ".port", "not-a-port")
+		RunKVSuccess(t, "set", "svc.config", "--path", ".port", "9090")
+
+		if output := RunKVSuccess(t, "get", "svc.config", "--path", ".port", "--type", "int"); output != "9090" {
+			t.Errorf("Expected 9090, got %q", output)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecipients(t *testing.T) {
+	t.Run("keygen", func(t *testing.T) {
+		SetupTestConfig(t, "")
+
+		publicKey := RunKVSuccess(t, "keygen")
+		if !strings.Contains(publicKey, "age1") {
+			t.Errorf("Expected public key, got: %s", publicKey)
+		}
+
+		output := RunKVSuccess(t, "keygen", "--public")
+		if !strings.Contains(publicKey, output) {
+			t.Errorf("Expected %q, got: %s", publicKey, output)
+		}
+
+		output = RunKVFailure(t, "keygen")
+		if !strings.Contains(output, "already exists") {
+			t.Errorf("Expected 'already exists' error, got: %s", output)
+		}
+	})
+
+	t.Run("lock and get with own identity", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "")
+		RunKVSuccess(t, "keygen")
+		publicKey := RunKVSuccess(t, "keygen", "--public")
+
+		RunKVSuccess(t, "set", "secret", "data")
+		RunKVSuccess(t, "lock", "secret", "--recipient", publicKey)
+
+		output := RunKVSuccess(t, "list")
+		if !strings.Contains(output, "[Locked]") {
+			t.Errorf("Expected locked value in list, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "secret")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		RunKVSuccess(t, "unlock", "secret")
+		output = RunKVSuccess(t, "get", "secret")
+		if output != "data" {
+			t.Errorf("Expected 'data' after unlock, got: %s", output)
+		}
+	})
+
+	t.Run("multiple recipients", func(t *testing.T) {
+		SetupTestDB(t)
+
+		// A teammate's identity lives somewhere else
+		teammateIdentity := filepath.Join(t.TempDir(), "teammate.txt")
+		SetupTestConfig(t, "identity: "+teammateIdentity+"\n")
+		RunKVSuccess(t, "keygen")
+		teammateKey := RunKVSuccess(t, "keygen", "--public")
+
+		SetupTestConfig(t, "")
+		RunKVSuccess(t, "keygen")
+
+		// Recipients can also come from a file
+		recipientsFile := filepath.Join(t.TempDir(), "recipients.txt")
+		if err := os.WriteFile(recipientsFile, []byte("# teammate\n"+teammateKey+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		RunKVSuccess(t, "set", "shared", "data")
+		RunKVSuccess(t, "lock", "shared", "-r", RunKVSuccess(t, "keygen", "--public"), "-r", recipientsFile)
+
+		output := RunKVSuccess(t, "get", "shared")
+		if output != "data" {
+			t.Errorf("Expected 'data' with own identity, got: %s", output)
+		}
+
+		SetupTestConfig(t, "identity: "+teammateIdentity+"\n")
+		output = RunKVSuccess(t, "get", "shared")
+		if output != "data" {
+			t.Errorf("Expected 'data' with teammate identity, got: %s", output)
+		}
+
+		// Someone else cannot decrypt
+		SetupTestConfig(t, "")
+		RunKVSuccess(t, "keygen")
+		output = RunKVFailure(t, "get", "shared")
+		if !strings.Contains(output, "Could not decrypt") {
+			t.Errorf("Expected decryption error, got: %s", output)
+		}
+	})
+
+	t.Run("missing identity", func(t *testing.T) {
+		SetupTestDB(t)
+		SetupTestConfig(t, "")
+		RunKVSuccess(t, "keygen")
+		publicKey := RunKVSuccess(t, "keygen", "--public")
+
+		RunKVSuccess(t, "set", "secret", "data")
+		RunKVSuccess(t, "lock", "secret", "--recipient", publicKey)
+
+		SetupTestConfig(t, "")
+		output := RunKVFailure(t, "get", "secret")
+		if !strings.Contains(output, "no identity was found") {
+			t.Errorf("Expected missing identity error, got: %s", output)
+		}
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secret", "data")
+
+		output := RunKVFailure(t, "lock", "secret", "--recipient", "not-a-key")
+		if !strings.Contains(output, "Invalid recipient") {
+			t.Errorf("Expected invalid recipient error, got: %s", output)
+		}
+
+		RunKVFailure(t, "lock", "secret", "--recipient", "age1abc", "--password=pass")
+	})
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRekeyCommand(t *testing.T) {
+	t.Run("re-encrypts outdated keys only", func(t *testing.T) {
+		SetupTestDB(t)
+
+		SetupTestConfig(t, "kdf: pbkdf2\n")
+		RunKVSuccess(t, "set", "old", "data1", "--password=pass")
+
+		SetupTestConfig(t, "kdf: argon2id\n")
+		RunKVSuccess(t, "set", "new", "data2", "--password=pass")
+		RunKVSuccess(t, "set", "plain", "data3")
+
+		output := RunKVSuccess(t, "rekey", "--all", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 1 key(s)") {
+			t.Errorf("Expected 1 re-encrypted key, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "rekey", "--all", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 0 key(s)") {
+			t.Errorf("Expected 0 re-encrypted keys, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "old", "--password=pass")
+		if output != "data1" {
+			t.Errorf("Expected 'data1', got: %s", output)
+		}
+
+		// Re-encryption happens in place, without history
+		output = RunKVSuccess(t, "history", "list", "old", "--output", "json")
+		if strings.Count(output, "timestamp") != 1 {
+			t.Errorf("Expected a single history entry, got: %s", output)
+		}
+	})
+
+	t.Run("wrong password rolls back", func(t *testing.T) {
+		SetupTestDB(t)
+
+		SetupTestConfig(t, "kdf: pbkdf2\n")
+		RunKVSuccess(t, "set", "a", "data1", "--password=pass")
+		RunKVSuccess(t, "set", "b", "data2", "--password=other")
+
+		SetupTestConfig(t, "kdf: argon2id\n")
+		output := RunKVFailure(t, "rekey", "a", "b", "--password=pass")
+		if !strings.Contains(output, "Wrong password") {
+			t.Errorf("Expected 'Wrong password' error, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "rekey", "a", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 1 key(s)") {
+			t.Errorf("Expected 'a' to still be outdated after rollback, got: %s", output)
+		}
+	})
+
+	t.Run("matched keys with other passwords are skipped", func(t *testing.T) {
+		SetupTestDB(t)
+
+		SetupTestConfig(t, "kdf: pbkdf2\n")
+		RunKVSuccess(t, "set", "a", "data1", "--password=pass")
+		RunKVSuccess(t, "set", "b", "data2", "--password=other")
+
+		SetupTestConfig(t, "kdf: argon2id\n")
+		output := RunKVFailure(t, "rekey", "--all", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 1 key(s)") || !strings.Contains(output, "does not decrypt: b") {
+			t.Errorf("Expected a to be re-encrypted and b skipped, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "rekey", "--all", "--password=other")
+		if !strings.Contains(output, "Re-encrypted 1 key(s)") {
+			t.Errorf("Expected b to still be outdated, got: %s", output)
+		}
+	})
+
+	t.Run("history is re-encrypted with --history", func(t *testing.T) {
+		SetupTestDB(t)
+
+		SetupTestConfig(t, "kdf: pbkdf2\n")
+		RunKVSuccess(t, "set", "a", "old", "--password=pass")
+		RunKVSuccess(t, "set", "a", "older", "--password=pass")
+
+		SetupTestConfig(t, "kdf: argon2id\n")
+		output := RunKVSuccess(t, "rekey", "a", "--history", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 1 key(s)") || !strings.Contains(output, "Re-encrypted 1 history record(s)") {
+			t.Errorf("Expected the key and its history to be re-encrypted, got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "rekey", "a", "--history", "--password=pass")
+		if !strings.Contains(output, "Re-encrypted 0 key(s)") || !strings.Contains(output, "Re-encrypted 0 history record(s)") {
+			t.Errorf("Expected nothing left to re-encrypt, got: %s", output)
+		}
+	})
+
+	t.Run("plain key fails", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "plain", "data")
+
+		output := RunKVFailure(t, "rekey", "plain", "--password=pass")
+		if !strings.Contains(output, "not locked") {
+			t.Errorf("Expected 'not locked' error, got: %s", output)
+		}
+	})
+}
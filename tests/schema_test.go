@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const appSchema = `{
+	"type": "object",
+	"properties": {
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+	},
+	"required": ["port"]
+}`
+
+// setSchema attaches given schema document to prefix
+func setSchema(t *testing.T, prefix string, document string) string {
+	t.Helper()
+
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schemaPath, []byte(document), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return RunKVSuccess(t, "schema", "set", prefix, schemaPath)
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("set validates writes", func(t *testing.T) {
+		SetupTestDB(t)
+		setSchema(t, "app.", appSchema)
+
+		RunKVSuccess(t, "set", "app.server", `{"port": 8080}`)
+		RunKVSuccess(t, "set", "other", `{"port": "not validated"}`)
+
+		output := RunKVFailure(t, "set", "app.server", `{"port": 0}`)
+		if !strings.Contains(output, `does not match schema "app."`) {
+			t.Errorf("Expected schema error, got: %s", output)
+		}
+
+		RunKVFailure(t, "set", "app.server", `{"host": "localhost"}`)
+		RunKVFailure(t, "set", "app.server", "not json")
+
+		if output := RunKVSuccess(t, "get", "app.server"); output != `{"port": 8080}` {
+			t.Errorf("Rejected writes should not change the value, got %q", output)
+		}
+	})
+
+	t.Run("scalar schemas and typed values", func(t *testing.T) {
+		SetupTestDB(t)
+		setSchema(t, "app.port", `{"type": "integer", "minimum": 1}`)
+
+		RunKVSuccess(t, "set", "app.port", "8080")
+		RunKVSuccess(t, "set", "app.port", "9090", "--type", "int")
+		RunKVFailure(t, "set", "app.port", "http")
+		RunKVFailure(t, "set", "app.port", "0")
+	})
+
+	t.Run("locked values are validated on set", func(t *testing.T) {
+		SetupTestDB(t)
+		setSchema(t, "app.port", `{"type": "integer"}`)
+
+		RunKVFailure(t, "set", "app.port", "http", "--password=pass")
+		RunKVSuccess(t, "set", "app.port", "8080", "--password=pass")
+	})
+
+	t.Run("copy and rename validate destination", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "draft.good", `{"port": 80}`)
+		RunKVSuccess(t, "set", "draft.bad", `{"port": -1}`)
+		setSchema(t, "app.", appSchema)
+
+		RunKVSuccess(t, "copy", "draft.good", "app.copied")
+		RunKVFailure(t, "copy", "draft.bad", "app.copied")
+
+		RunKVSuccess(t, "rename", "draft.good", "app.renamed")
+		RunKVFailure(t, "rename", "draft.bad", "app.renamed-bad")
+
+		if output := RunKVSuccess(t, "get", "draft.bad"); output != `{"port": -1}` {
+			t.Errorf("Rejected rename should keep the key, got %q", output)
+		}
+	})
+
+	t.Run("check reports existing violations", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "app.good", `{"port": 80}`)
+		RunKVSuccess(t, "set", "app.bad", `{"port": 70000}`)
+
+		output := setSchema(t, "app.", appSchema)
+		if !strings.Contains(output, "1 existing value(s)") {
+			t.Errorf("Expected warning about existing violations, got: %s", output)
+		}
+
+		output = RunKVFailure(t, "schema", "check")
+		if !strings.Contains(output, "app.bad") || strings.Contains(output, "app.good") {
+			t.Errorf("Expected only app.bad to be reported, got: %s", output)
+		}
+
+		RunKVSuccess(t, "set", "app.bad", `{"port": 7000}`, "--type", "json")
+		if output := RunKVSuccess(t, "schema", "check", "app."); !strings.Contains(output, "All values match") {
+			t.Errorf("Expected no violations, got: %s", output)
+		}
+	})
+
+	t.Run("manage schemas", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "schema", "set", "app.", "-")
+		setSchema(t, "app.", appSchema)
+
+		if output := RunKVSuccess(t, "schema", "get", "app."); !strings.Contains(output, `"required"`) {
+			t.Errorf("Expected schema document, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "schema", "list"); !strings.Contains(output, "app.") {
+			t.Errorf("Expected schema in list, got: %s", output)
+		}
+
+		RunKVSuccess(t, "schema", "remove", "app.")
+		RunKVFailure(t, "schema", "get", "app.")
+		RunKVFailure(t, "schema", "remove", "app.")
+
+		RunKVSuccess(t, "set", "app.server", "anything")
+	})
+
+	t.Run("invalid schema fails", func(t *testing.T) {
+		SetupTestDB(t)
+
+		schemaPath := filepath.Join(t.TempDir(), "schema.json")
+		if err := os.WriteFile(schemaPath, []byte(`{"type": "nope"}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		RunKVFailure(t, "schema", "set", "app.", schemaPath)
+	})
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AmrSaber/kv/src/common"
+)
+
+// dbFilesContain reports whether the database file or its WAL file contains text
+func dbFilesContain(t *testing.T, text string) bool {
+	t.Helper()
+
+	for _, path := range []string{common.GetDBPath(), common.GetDBPath() + "-wal"} {
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestScrubHistory(t *testing.T) {
+	t.Run("lock with scrub history", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "token", "old-plain-secret")
+		RunKVSuccess(t, "set", "token", "new-plain-secret")
+
+		output := RunKVSuccess(t, "lock", "token", "--scrub-history", "--password=pass")
+		if !strings.Contains(output, "Scrubbed 1 history record(s)") {
+			t.Errorf("Expected 1 scrubbed record, got: %s", output)
+		}
+
+		if dbFilesContain(t, "old-plain-secret") || dbFilesContain(t, "new-plain-secret") {
+			t.Error("Plain-text value still present in database files")
+		}
+
+		// Older value is still available, encrypted with the same password
+		RunKVSuccess(t, "history", "revert", "token")
+		output = RunKVSuccess(t, "get", "token", "--password=pass")
+		if output != "old-plain-secret" {
+			t.Errorf("Expected 'old-plain-secret', got: %s", output)
+		}
+	})
+
+	t.Run("db scrub deletes plain history", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "token", "old-plain-secret")
+		RunKVSuccess(t, "set", "token", "new-value")
+		RunKVSuccess(t, "lock", "token", "--password=pass")
+		RunKVSuccess(t, "set", "plain", "v1")
+		RunKVSuccess(t, "set", "plain", "v2")
+
+		output := RunKVSuccess(t, "db", "scrub")
+		if !strings.Contains(output, "Scrubbed 1 history record(s)") {
+			t.Errorf("Expected 1 scrubbed record, got: %s", output)
+		}
+
+		if dbFilesContain(t, "old-plain-secret") {
+			t.Error("Plain-text value still present in database files")
+		}
+
+		// History of unlocked keys is untouched
+		output = RunKVSuccess(t, "history", "list", "plain", "--output", "json")
+		if !strings.Contains(output, "v1") {
+			t.Errorf("Expected unlocked key history to remain, got: %s", output)
+		}
+	})
+
+	t.Run("db scrub with password encrypts history", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "token", "old-plain-secret")
+		RunKVSuccess(t, "set", "token", "new-value")
+		RunKVSuccess(t, "lock", "token", "--password=pass")
+
+		RunKVSuccess(t, "db", "scrub", "--password=pass")
+
+		if dbFilesContain(t, "old-plain-secret") {
+			t.Error("Plain-text value still present in database files")
+		}
+
+		RunKVSuccess(t, "history", "revert", "token")
+		output := RunKVSuccess(t, "get", "token", "--password=pass")
+		if output != "old-plain-secret" {
+			t.Errorf("Expected 'old-plain-secret', got: %s", output)
+		}
+	})
+}
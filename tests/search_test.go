@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// searchMatches returns "key:in" for each result of a search
+func searchMatches(t *testing.T, args ...string) []string {
+	t.Helper()
+
+	output := RunKVSuccess(t, append([]string{"search"}, append(args, "-o", "json")...)...)
+
+	var results []struct {
+		Key string `json:"key"`
+		In  string `json:"in"`
+	}
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("Invalid JSON output: %v: %s", err, output)
+	}
+
+	matches := []string{}
+	for _, result := range results {
+		matches = append(matches, result.Key+":"+result.In)
+	}
+
+	return matches
+}
+
+func TestSearch(t *testing.T) {
+	t.Run("keys, values, descriptions and history", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "postgres.url", "postgres://localhost/dev", "--desc", "Postgres for dev")
+		RunKVSuccess(t, "set", "db.url", "postgres://localhost/prod")
+		RunKVSuccess(t, "set", "db.url", "mysql://localhost/prod")
+		RunKVSuccess(t, "set", "other", "value", "--desc", "Not related")
+
+		expected := "db.url:history postgres.url:keys postgres.url:values postgres.url:desc"
+		if matches := searchMatches(t, "POSTGRES", "--in", "keys,values,desc,history"); strings.Join(matches, " ") != expected {
+			t.Errorf("Expected %s, got %v", expected, matches)
+		}
+
+		expected = "postgres.url:keys postgres.url:values postgres.url:desc"
+		if matches := searchMatches(t, "postgres"); strings.Join(matches, " ") != expected {
+			t.Errorf("Expected %s by default, got %v", expected, matches)
+		}
+
+		if matches := searchMatches(t, "related", "--in", "desc"); len(matches) != 1 || matches[0] != "other:desc" {
+			t.Errorf("Expected other:desc, got %v", matches)
+		}
+
+		// Short queries are matched without the index
+		if matches := searchMatches(t, "db", "--in", "keys"); len(matches) != 1 || matches[0] != "db.url:keys" {
+			t.Errorf("Expected db.url:keys, got %v", matches)
+		}
+
+		// Queries are not parsed as search syntax
+		RunKVSuccess(t, "set", "quoted", `say "hi" OR bye 100%`)
+		if matches := searchMatches(t, `"hi" OR`, "--in", "values"); len(matches) != 1 || matches[0] != "quoted:values" {
+			t.Errorf("Expected quoted:values, got %v", matches)
+		}
+
+		if matches := searchMatches(t, "0%", "--in", "values"); len(matches) != 1 || matches[0] != "quoted:values" {
+			t.Errorf("Expected quoted:values, got %v", matches)
+		}
+
+		RunKVFailure(t, "search", "postgres", "--in", "everywhere")
+	})
+
+	t.Run("locked and hidden values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "api.token", "secret-token", "--password=pass")
+		RunKVSuccess(t, "set", "api.note", "token rotation notes", "--hidden")
+		RunKVSuccess(t, "set", "api.plain", "plain token")
+		RunKVSuccess(t, "lock", "api.plain", "--password=pass")
+
+		if matches := searchMatches(t, "token", "--in", "values,history", "--show"); len(matches) != 1 || matches[0] != "api.note:values" {
+			t.Errorf("Expected only the hidden value with --show, got %v", matches)
+		}
+
+		if matches := searchMatches(t, "token", "--in", "values"); len(matches) != 0 {
+			t.Errorf("Expected no matches without --show, got %v", matches)
+		}
+
+		// Names of locked keys can still be searched
+		if matches := searchMatches(t, "api.token", "--in", "keys"); len(matches) != 1 {
+			t.Errorf("Expected api.token:keys, got %v", matches)
+		}
+
+		if output := RunKVSuccess(t, "search", "token", "--show"); strings.Contains(output, "secret-token") {
+			t.Errorf("Locked values should never be printed, got: %s", output)
+		}
+	})
+
+	t.Run("index follows writes", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "old-name", "some value", "--desc", "first description")
+		RunKVSuccess(t, "rename", "old-name", "new-name")
+		RunKVSuccess(t, "annotate", "new-name", "--desc", "second description")
+
+		if matches := searchMatches(t, "value"); len(matches) != 1 || matches[0] != "new-name:values" {
+			t.Errorf("Expected new-name:values, got %v", matches)
+		}
+
+		if matches := searchMatches(t, "first", "--in", "desc"); len(matches) != 0 {
+			t.Errorf("Expected old description to be dropped, got %v", matches)
+		}
+
+		RunKVSuccess(t, "delete", "new-name", "--prune")
+		if matches := searchMatches(t, "description", "--in", "keys,values,desc,history"); len(matches) != 0 {
+			t.Errorf("Expected no matches after pruning, got %v", matches)
+		}
+	})
+
+	t.Run("encrypted database", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "db.url", "postgres://localhost/dev", "--desc", "Postgres for dev")
+		RunKVSuccess(t, "db", "encrypt", "--password=dbpass")
+		t.Setenv("KV_DB_PASSWORD", "dbpass")
+
+		RunKVSuccess(t, "set", "other", "postgres again")
+
+		expected := "db.url:values db.url:desc other:values"
+		if matches := searchMatches(t, "postgres"); strings.Join(matches, " ") != expected {
+			t.Errorf("Expected %s, got %v", expected, matches)
+		}
+
+		RunKVSuccess(t, "db", "decrypt")
+		if matches := searchMatches(t, "postgres"); strings.Join(matches, " ") != expected {
+			t.Errorf("Expected %s after decrypting, got %v", expected, matches)
+		}
+	})
+}
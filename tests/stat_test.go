@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	t.Run("metadata without value", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "api-key", "first")
+		RunKVSuccess(t, "set", "api-key", "second-value", "--type", "string", "--expires-after", "1h")
+		RunKVSuccess(t, "hide", "api-key")
+
+		var stats map[string]any
+		output := RunKVSuccess(t, "stat", "api-key", "-o", "json")
+		if err := json.Unmarshal([]byte(output), &stats); err != nil {
+			t.Fatalf("Invalid JSON output: %v: %s", err, output)
+		}
+
+		expected := map[string]any{
+			"key":      "api-key",
+			"type":     "string",
+			"isHidden": true,
+			"isLocked": false,
+			"size":     float64(len("second-value")),
+			"versions": float64(2),
+		}
+
+		for field, value := range expected {
+			if stats[field] != value {
+				t.Errorf("Expected %s to be %#v, got %#v", field, value, stats[field])
+			}
+		}
+
+		for _, field := range []string{"version", "createdAt", "modifiedAt", "expiresAt"} {
+			if _, found := stats[field]; !found {
+				t.Errorf("Expected %s in output, got: %s", field, output)
+			}
+		}
+
+		for _, format := range []string{"json", "yaml", "table"} {
+			if output := RunKVSuccess(t, "stat", "api-key", "-o", format); strings.Contains(output, "second-value") {
+				t.Errorf("Value should never be printed, got: %s", output)
+			}
+		}
+	})
+
+	t.Run("links, vaults and locked keys", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "secret", "s3cr3t", "--password=pass")
+		RunKVSuccess(t, "link", "alias", "secret")
+
+		output := RunKVSuccess(t, "stat", "secret", "-o", "yaml")
+		if !strings.Contains(output, "is-locked: true") || strings.Contains(output, "s3cr3t") {
+			t.Errorf("Expected locked key without value, got: %s", output)
+		}
+
+		if output := RunKVSuccess(t, "stat", "alias", "-o", "yaml"); !strings.Contains(output, "link-target: secret") {
+			t.Errorf("Expected link target, got: %s", output)
+		}
+
+		// get --meta follows links
+		if output := RunKVSuccess(t, "get", "alias", "--meta"); !strings.Contains(output, "key: secret") {
+			t.Errorf("Expected metadata of the link target, got: %s", output)
+		}
+
+		RunKVSuccess(t, "vault", "create", "team.", "--password=vaultpass")
+		RunKVSuccess(t, "set", "team.token", "abc", "--password=vaultpass")
+
+		if output := RunKVSuccess(t, "stat", "team.token"); !strings.Contains(output, "team.") {
+			t.Errorf("Expected vault in output, got: %s", output)
+		}
+
+		RunKVFailure(t, "stat", "missing")
+		RunKVFailure(t, "stat", "secret", "-o", "xml")
+	})
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTypedValues(t *testing.T) {
+	t.Run("validation on write", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+		RunKVSuccess(t, "set", "debug", "true", "--type", "bool")
+		RunKVSuccess(t, "set", "config", `{"port": 8080}`, "--type", "json")
+		RunKVSuccess(t, "set", "endpoint", "https://example.com/api", "--type", "url")
+		RunKVSuccess(t, "set", "timeout", "1h30m", "--type", "duration")
+
+		RunKVFailure(t, "set", "bad-port", "80.5", "--type", "int")
+		RunKVFailure(t, "set", "bad-config", `{"port": }`, "--type", "json")
+		RunKVFailure(t, "set", "bad-endpoint", "/relative/path", "--type", "url")
+		RunKVFailure(t, "set", "bad-timeout", "soon", "--type", "duration")
+		RunKVFailure(t, "set", "bad-type", "1.5", "--type", "float")
+	})
+
+	t.Run("type is kept for following values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+		RunKVFailure(t, "set", "port", "not-a-port")
+		RunKVSuccess(t, "set", "port", "9090")
+
+		// Another type can be given explicitly
+		RunKVSuccess(t, "set", "port", "not-a-port", "--type", "string")
+
+		output := RunKVSuccess(t, "history", "list", "port", "-o", "json")
+		if !strings.Contains(output, `"type": "int"`) || !strings.Contains(output, `"type": "string"`) {
+			t.Errorf("Expected type of each version in history, got: %s", output)
+		}
+	})
+
+	t.Run("typed list output", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080", "--type", "int")
+		RunKVSuccess(t, "set", "debug", "false", "--type", "bool")
+		RunKVSuccess(t, "set", "config", `{"nested": [1, 2]}`, "--type", "json")
+		RunKVSuccess(t, "set", "plain", "8080")
+
+		var items []map[string]any
+		if err := json.Unmarshal([]byte(RunKVSuccess(t, "list", "-o", "json")), &items); err != nil {
+			t.Fatal(err)
+		}
+
+		values := map[string]any{}
+		for _, item := range items {
+			values[item["key"].(string)] = item["value"]
+		}
+
+		if values["port"] != float64(8080) {
+			t.Errorf("Expected port to be a number, got %#v", values["port"])
+		}
+
+		if values["debug"] != false {
+			t.Errorf("Expected debug to be a boolean, got %#v", values["debug"])
+		}
+
+		if _, ok := values["config"].(map[string]any); !ok {
+			t.Errorf("Expected config to be an object, got %#v", values["config"])
+		}
+
+		if values["plain"] != "8080" {
+			t.Errorf("Expected untyped value to be a string, got %#v", values["plain"])
+		}
+
+		output := RunKVSuccess(t, "list", "-o", "yaml")
+		if !strings.Contains(output, "value: 8080\n  type: int") || !strings.Contains(output, `value: "8080"`) {
+			t.Errorf("Expected typed YAML values, got: %s", output)
+		}
+	})
+
+	t.Run("get enforces type", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVSuccess(t, "set", "port", "8080")
+		RunKVSuccess(t, "set", "host", "localhost")
+
+		if output := RunKVSuccess(t, "get", "port", "--type", "int"); output != "8080" {
+			t.Errorf("Expected value, got %q", output)
+		}
+
+		output := RunKVFailure(t, "get", "host", "--type", "int")
+		if !strings.Contains(output, "not a valid int") {
+			t.Errorf("Expected type error, got: %s", output)
+		}
+	})
+
+	t.Run("locked typed values", func(t *testing.T) {
+		SetupTestDB(t)
+
+		RunKVFailure(t, "set", "pin", "12ab", "--type", "int", "--password=pass")
+		RunKVSuccess(t, "set", "pin", "1234", "--type", "int", "--password=pass")
+
+		if output := RunKVSuccess(t, "get", "pin", "--type", "int", "--password=pass"); output != "1234" {
+			t.Errorf("Expected decrypted value, got %q", output)
+		}
+	})
+}
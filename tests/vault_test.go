@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+// setupVault creates a vault for prefix, and makes sure the agent is stopped when the test ends
+func setupVault(t *testing.T, prefix string, password string) {
+	t.Helper()
+
+	RunKVSuccess(t, "vault", "create", prefix, "--password="+password)
+
+	t.Cleanup(func() {
+		_, _ = RunKV(t, "vault", "lock")
+	})
+}
+
+func TestVaultCreate(t *testing.T) {
+	t.Run("encrypts existing keys under prefix", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secrets.api", "key1")
+		RunKVSuccess(t, "set", "public.data", "data")
+
+		setupVault(t, "secrets.", "pass")
+
+		output := RunKVSuccess(t, "list", "secrets.")
+		if !strings.Contains(output, "[Locked]") {
+			t.Error("Keys under vault should be locked")
+		}
+
+		output = RunKVSuccess(t, "get", "public.data")
+		if output != "data" {
+			t.Errorf("Expected 'data', got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "get", "secrets.api", "--password=pass")
+		if output != "key1" {
+			t.Errorf("Expected 'key1', got: %s", output)
+		}
+	})
+
+	t.Run("fails on overlapping vaults", func(t *testing.T) {
+		SetupTestDB(t)
+		setupVault(t, "secrets.", "pass")
+
+		output := RunKVFailure(t, "vault", "create", "secrets.db.", "--password=pass")
+		if !strings.Contains(output, "overlaps") {
+			t.Errorf("Expected 'overlaps' error, got: %s", output)
+		}
+	})
+
+	t.Run("fails on locked keys under prefix", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secrets.api", "key1", "--password=other")
+
+		output := RunKVFailure(t, "vault", "create", "secrets.", "--password=pass")
+		if !strings.Contains(output, "unlock it") {
+			t.Errorf("Expected 'unlock it' error, got: %s", output)
+		}
+	})
+}
+
+func TestVaultUnlock(t *testing.T) {
+	t.Run("get and set without password while unlocked", func(t *testing.T) {
+		SetupTestDB(t)
+		setupVault(t, "secrets.", "pass")
+
+		output := RunKVFailure(t, "set", "secrets.api", "key1")
+		if !strings.Contains(output, "is locked") {
+			t.Errorf("Expected 'is locked' error, got: %s", output)
+		}
+
+		RunKVSuccess(t, "vault", "unlock", "secrets.", "--password=pass")
+		RunKVSuccess(t, "set", "secrets.api", "key1")
+
+		output = RunKVSuccess(t, "get", "secrets.api")
+		if output != "key1" {
+			t.Errorf("Expected 'key1', got: %s", output)
+		}
+
+		output = RunKVSuccess(t, "list", "secrets.")
+		if !strings.Contains(output, "[Locked]") {
+			t.Error("Keys set in an unlocked vault should be encrypted")
+		}
+
+		output = RunKVSuccess(t, "vault", "list")
+		if !strings.Contains(output, "Unlocked") {
+			t.Errorf("Expected vault to be unlocked, got: %s", output)
+		}
+
+		RunKVSuccess(t, "vault", "lock", "secrets.")
+
+		output = RunKVFailure(t, "get", "secrets.api")
+		if !strings.Contains(output, "is locked") {
+			t.Errorf("Expected 'is locked' error, got: %s", output)
+		}
+	})
+
+	t.Run("unlock with wrong password fails", func(t *testing.T) {
+		SetupTestDB(t)
+		setupVault(t, "secrets.", "pass")
+
+		output := RunKVFailure(t, "vault", "unlock", "secrets.", "--password=wrong")
+		if !strings.Contains(output, "Wrong password") {
+			t.Errorf("Expected 'Wrong password' error, got: %s", output)
+		}
+	})
+
+	t.Run("unlock non-existent vault fails", func(t *testing.T) {
+		SetupTestDB(t)
+
+		output := RunKVFailure(t, "vault", "unlock", "secrets.", "--password=pass")
+		if !strings.Contains(output, "does not exist") {
+			t.Errorf("Expected 'does not exist' error, got: %s", output)
+		}
+	})
+}
+
+func TestVaultBoundaries(t *testing.T) {
+	t.Run("copy and rename across vault fail", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "public.data", "data")
+		setupVault(t, "secrets.", "pass")
+		RunKVSuccess(t, "set", "secrets.api", "key1", "--password=pass")
+
+		output := RunKVFailure(t, "copy", "public.data", "secrets.data")
+		if !strings.Contains(output, "vault boundaries") {
+			t.Errorf("Expected 'vault boundaries' error, got: %s", output)
+		}
+
+		output = RunKVFailure(t, "rename", "secrets.api", "public.api")
+		if !strings.Contains(output, "vault boundaries") {
+			t.Errorf("Expected 'vault boundaries' error, got: %s", output)
+		}
+
+		// Moving inside the same vault keeps the value readable
+		RunKVSuccess(t, "rename", "secrets.api", "secrets.api2")
+		output = RunKVSuccess(t, "get", "secrets.api2", "--password=pass")
+		if output != "key1" {
+			t.Errorf("Expected 'key1', got: %s", output)
+		}
+	})
+}
+
+func TestVaultRemove(t *testing.T) {
+	t.Run("decrypts keys back to plain text", func(t *testing.T) {
+		SetupTestDB(t)
+		RunKVSuccess(t, "set", "secrets.api", "key1")
+		setupVault(t, "secrets.", "pass")
+
+		RunKVFailure(t, "vault", "remove", "secrets.", "--password=wrong")
+		RunKVSuccess(t, "vault", "remove", "secrets.", "--password=pass")
+
+		output := RunKVSuccess(t, "get", "secrets.api")
+		if output != "key1" {
+			t.Errorf("Expected 'key1', got: %s", output)
+		}
+
+		RunKVSuccess(t, "set", "secrets.other", "plain")
+		output = RunKVSuccess(t, "list", "secrets.")
+		if strings.Contains(output, "[Locked]") {
+			t.Error("Keys should not be locked after vault removal")
+		}
+	})
+}